@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	"../greetpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// benchFunc performs one unit of work and reports how many messages it moved.
+// Without latencies, the whole call is one latency sample.
+type benchFunc func(ctx context.Context, client greetpb.GreetServiceClient, worker int) (messages int64, latencies []time.Duration, err error)
+
+var benches = map[string]benchFunc{
+	"unary":         benchUnary,
+	"server-stream": benchServerStream,
+	"client-stream": benchClientStream,
+	"bidi":          benchBidi,
+}
+
+// rpcOrder is the order in which "all" runs the benchmarks.
+var rpcOrder = []string{"unary", "server-stream", "client-stream", "bidi"}
+
+// messagesPerStream is the number of requests sent per client stream and per
+// bi-directional stream.
+var messagesPerStream = 10
+
+func request(worker int) *greetpb.GreetRequest {
+	return &greetpb.GreetRequest{
+		Greeting: &greetpb.Greeting{
+			FirstName: "bench",
+			LastName:  strconv.Itoa(worker),
+		},
+	}
+}
+
+// Failed calls are retried after a backoff that grows from minBackoff to
+// maxBackoff, so that a server that is down is not hammered with calls.
+var (
+	minBackoff = 10 * time.Millisecond
+	maxBackoff = time.Second
+)
+
+// run drives fn from concurrency workers until duration elapses.
+func run(client greetpb.GreetServiceClient, rpc string, fn benchFunc, concurrency int, duration time.Duration) Result {
+	ctx, cancel := context.WithTimeout(context.Background(), duration)
+	defer cancel()
+
+	recs := make([]*recorder, concurrency)
+	var wg sync.WaitGroup
+	start := time.Now()
+	for i := 0; i < concurrency; i++ {
+		rec := &recorder{}
+		recs[i] = rec
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			backoff := minBackoff
+			for ctx.Err() == nil {
+				begin := time.Now()
+				n, latencies, err := fn(ctx, client, worker)
+				if ctx.Err() != nil || status.Code(err) == codes.DeadlineExceeded {
+					// Calls cut short by the deadline are not counted. The
+					// server may report the deadline a moment before ctx
+					// sees it.
+					return
+				}
+				if err != nil {
+					rec.fail()
+					select {
+					case <-time.After(backoff):
+					case <-ctx.Done():
+						return
+					}
+					if backoff *= 2; backoff > maxBackoff {
+						backoff = maxBackoff
+					}
+					continue
+				}
+				backoff = minBackoff
+				if latencies == nil {
+					rec.observe(n, time.Since(begin))
+				} else {
+					rec.observe(n, latencies...)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	return summarise(rpc, concurrency, time.Since(start), recs)
+}
+
+// Unary: one latency sample per call.
+func benchUnary(ctx context.Context, client greetpb.GreetServiceClient, worker int) (int64, []time.Duration, error) {
+	_, err := client.Greet(ctx, request(worker))
+	if err != nil {
+		return 0, nil, err
+	}
+	return 1, nil, nil
+}
+
+// Server Stream: one latency sample per complete stream.
+func benchServerStream(ctx context.Context, client greetpb.GreetServiceClient, worker int) (int64, []time.Duration, error) {
+	stream, err := client.GreetManyTimes(ctx, request(worker))
+	if err != nil {
+		return 0, nil, err
+	}
+	var n int64
+	for {
+		_, err := stream.Recv()
+		if err == io.EOF {
+			return n, nil, nil
+		}
+		if err != nil {
+			return n, nil, err
+		}
+		n++
+	}
+}
+
+// Client Stream: one latency sample per complete stream.
+func benchClientStream(ctx context.Context, client greetpb.GreetServiceClient, worker int) (int64, []time.Duration, error) {
+	stream, err := client.LongGreet(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+	req := request(worker)
+	for i := 0; i < messagesPerStream; i++ {
+		if err := stream.Send(req); err != nil {
+			return 0, nil, err
+		}
+	}
+	if _, err := stream.CloseAndRecv(); err != nil {
+		return 0, nil, err
+	}
+	return int64(messagesPerStream), nil, nil
+}
+
+// Bi-Directional Stream: one latency sample per round trip, from sending a
+// message to receiving its reply. Opening and closing the stream are left out.
+func benchBidi(ctx context.Context, client greetpb.GreetServiceClient, worker int) (int64, []time.Duration, error) {
+	stream, err := client.GreetEveryOne(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+	req := request(worker)
+	latencies := make([]time.Duration, 0, messagesPerStream)
+	for i := 0; i < messagesPerStream; i++ {
+		begin := time.Now()
+		if err := stream.Send(req); err != nil {
+			return 0, nil, err
+		}
+		if _, err := stream.Recv(); err != nil {
+			return 0, nil, err
+		}
+		latencies = append(latencies, time.Since(begin))
+	}
+	if err := stream.CloseSend(); err != nil {
+		return 0, nil, err
+	}
+	if _, err := stream.Recv(); err != io.EOF {
+		return 0, nil, err
+	}
+	return int64(len(latencies)), latencies, nil
+}
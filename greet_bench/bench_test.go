@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"../greetlog"
+	"../greetpb"
+	"../greetpb/greetmock"
+	"../greettest"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// inProcessClient serves greetserver.Server over bufconn for the duration
+// of tb, with per-call logging turned off.
+func inProcessClient(tb testing.TB) greetpb.GreetServiceClient {
+	tb.Helper()
+	previous := greetlog.SetLevel(greetlog.Error)
+	tb.Cleanup(func() { greetlog.SetLevel(previous) })
+	srv := greettest.NewServer(nil)
+	tb.Cleanup(srv.Close)
+	client, conn, err := srv.Client(context.Background())
+	if err != nil {
+		tb.Fatalf("Client: %v", err)
+	}
+	tb.Cleanup(func() { conn.Close() })
+	return client
+}
+
+func benchmark(b *testing.B, fn benchFunc) {
+	client := inProcessClient(b)
+	var workers int64
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		worker := int(atomic.AddInt64(&workers, 1))
+		for pb.Next() {
+			if _, _, err := fn(context.Background(), client, worker); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
+
+func BenchmarkUnary(b *testing.B) { benchmark(b, benchUnary) }
+
+func BenchmarkServerStream(b *testing.B) { benchmark(b, benchServerStream) }
+
+func BenchmarkClientStream(b *testing.B) { benchmark(b, benchClientStream) }
+
+func BenchmarkBidi(b *testing.B) { benchmark(b, benchBidi) }
+
+func TestRunInProcess(t *testing.T) {
+	client := inProcessClient(t)
+	for _, name := range rpcOrder {
+		res := run(client, name, benches[name], 2, 200*time.Millisecond)
+		if res.Calls == 0 || res.Errors != 0 {
+			t.Errorf("%s: %d calls, %d errors; want calls and no errors", name, res.Calls, res.Errors)
+		}
+		if res.P50Ms > res.P90Ms || res.P90Ms > res.P99Ms {
+			t.Errorf("%s: percentiles out of order: %v %v %v", name, res.P50Ms, res.P90Ms, res.P99Ms)
+		}
+	}
+}
+
+// Every bidi round trip is a latency sample of its own.
+func TestBidiSamplesEachRoundTrip(t *testing.T) {
+	client := inProcessClient(t)
+	begin := time.Now()
+	n, latencies, err := benchBidi(context.Background(), client, 1)
+	elapsed := time.Since(begin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(messagesPerStream) || len(latencies) != messagesPerStream {
+		t.Fatalf("%d messages, %d samples; want %d of each", n, len(latencies), messagesPerStream)
+	}
+	var total time.Duration
+	for _, d := range latencies {
+		if d <= 0 {
+			t.Errorf("sample %v, want a positive round trip", d)
+		}
+		total += d
+	}
+	if total >= elapsed {
+		t.Errorf("samples add up to %v of a %v stream, want the setup left out", total, elapsed)
+	}
+}
+
+func TestRunBacksOffOnErrors(t *testing.T) {
+	var calls int64
+	client := &greetmock.Client{
+		GreetFunc: func(ctx context.Context, req *greetpb.GreetRequest) (*greetpb.GreetResponse, error) {
+			atomic.AddInt64(&calls, 1)
+			return nil, status.Error(codes.Unavailable, "server down")
+		},
+	}
+	res := run(client, "unary", benchUnary, 1, 300*time.Millisecond)
+	// 10, 20, 40, 80 and 160ms of backoff fit in 300ms: about six calls,
+	// where a busy loop would make thousands.
+	if n := atomic.LoadInt64(&calls); n > 10 {
+		t.Errorf("%d calls in 300ms against a failing server, want a handful", n)
+	}
+	if res.Errors == 0 || res.Calls != 0 {
+		t.Errorf("Errors = %d, Calls = %d; want errors only", res.Errors, res.Calls)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	var sorted []time.Duration
+	for i := 1; i <= 100; i++ {
+		sorted = append(sorted, time.Duration(i)*time.Millisecond)
+	}
+	for _, tc := range []struct {
+		p    int
+		want float64
+	}{{50, 50}, {90, 90}, {99, 99}} {
+		if got := percentile(sorted, tc.p); got != tc.want {
+			t.Errorf("percentile(%d) = %v, want %v", tc.p, got, tc.want)
+		}
+	}
+	if got := percentile(nil, 50); got != 0 {
+		t.Errorf("percentile of nothing = %v, want 0", got)
+	}
+}
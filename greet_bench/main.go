@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"time"
+
+	"../greetpb"
+	"../greettest"
+	"google.golang.org/grpc"
+)
+
+func main() {
+	addr := flag.String("addr", "localhost:50051", "greet_server address")
+	inProcess := flag.Bool("inprocess", false, "benchmark an in-process server over bufconn instead of -addr")
+	rpc := flag.String("rpc", "all", "rpc to benchmark: unary, server-stream, client-stream, bidi or all")
+	concurrency := flag.Int("c", 10, "number of concurrent workers")
+	duration := flag.Duration("d", 10*time.Second, "duration of each benchmark")
+	flag.IntVar(&messagesPerStream, "n", messagesPerStream, "messages sent per client and bi-directional stream")
+	asJSON := flag.Bool("json", false, "print results as JSON")
+	flag.Parse()
+
+	rpcs := rpcOrder
+	if *rpc != "all" {
+		if _, ok := benches[*rpc]; !ok {
+			log.Fatalf("Unknown rpc %q", *rpc)
+		}
+		rpcs = []string{*rpc}
+	}
+	if *concurrency < 1 {
+		log.Fatalf("Concurrency must be at least 1, got %d", *concurrency)
+	}
+
+	var conn *grpc.ClientConn
+	var err error
+	if *inProcess {
+		srv := greettest.NewServer(nil)
+		defer srv.Close()
+		conn, err = srv.Dial(context.Background())
+	} else {
+		conn, err = grpc.Dial(*addr, grpc.WithInsecure())
+	}
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer conn.Close()
+	client := greetpb.NewGreetServiceClient(conn)
+
+	var results []Result
+	for _, name := range rpcs {
+		log.Printf("Benchmarking %s with %d workers for %v..!!", name, *concurrency, *duration)
+		res := run(client, name, benches[name], *concurrency, *duration)
+		if res.Calls == 0 && name == "server-stream" {
+			log.Printf("No server-stream call completed in %v; each one lasts 10 times the server's -stream-interval, so run greet_server with -stream-interval 0 or raise -d", *duration)
+		}
+		results = append(results, res)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(results); err != nil {
+			log.Fatalln(err)
+		}
+		return
+	}
+	printText(os.Stdout, results)
+}
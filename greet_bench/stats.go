@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// Result summarises one benchmark run against a single RPC type.
+type Result struct {
+	RPC            string  `json:"rpc"`
+	Concurrency    int     `json:"concurrency"`
+	Seconds        float64 `json:"seconds"`
+	Calls          int64   `json:"calls"`
+	Messages       int64   `json:"messages"`
+	Errors         int64   `json:"errors"`
+	CallsPerSec    float64 `json:"calls_per_sec"`
+	MessagesPerSec float64 `json:"messages_per_sec"`
+	P50Ms          float64 `json:"p50_ms"`
+	P90Ms          float64 `json:"p90_ms"`
+	P99Ms          float64 `json:"p99_ms"`
+}
+
+// recorder collects latencies and counters for a single worker so workers
+// never contend on shared state while the benchmark is running.
+type recorder struct {
+	latencies []time.Duration
+	calls     int64
+	messages  int64
+	errors    int64
+}
+
+// observe records a successful call that moved messages, with its latency
+// samples.
+func (r *recorder) observe(messages int64, latencies ...time.Duration) {
+	r.latencies = append(r.latencies, latencies...)
+	r.calls++
+	r.messages += messages
+}
+
+func (r *recorder) fail() {
+	r.errors++
+}
+
+func summarise(rpc string, concurrency int, elapsed time.Duration, recs []*recorder) Result {
+	res := Result{RPC: rpc, Concurrency: concurrency, Seconds: elapsed.Seconds()}
+	var all []time.Duration
+	for _, r := range recs {
+		all = append(all, r.latencies...)
+		res.Calls += r.calls
+		res.Messages += r.messages
+		res.Errors += r.errors
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i] < all[j] })
+	if elapsed > 0 {
+		res.CallsPerSec = float64(res.Calls) / elapsed.Seconds()
+		res.MessagesPerSec = float64(res.Messages) / elapsed.Seconds()
+	}
+	res.P50Ms = percentile(all, 50)
+	res.P90Ms = percentile(all, 90)
+	res.P99Ms = percentile(all, 99)
+	return res
+}
+
+// percentile returns the p-th percentile of sorted in milliseconds using the
+// nearest-rank method.
+func percentile(sorted []time.Duration, p int) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return float64(sorted[rank-1]) / float64(time.Millisecond)
+}
+
+func printText(w io.Writer, results []Result) {
+	fmt.Fprintf(w, "%-14s %5s %8s %10s %10s %7s %10s %12s %9s %9s %9s\n",
+		"rpc", "conc", "secs", "calls", "messages", "errors", "calls/s", "messages/s", "p50(ms)", "p90(ms)", "p99(ms)")
+	for _, r := range results {
+		fmt.Fprintf(w, "%-14s %5d %8.2f %10d %10d %7d %10.1f %12.1f %9.3f %9.3f %9.3f\n",
+			r.RPC, r.Concurrency, r.Seconds, r.Calls, r.Messages, r.Errors, r.CallsPerSec, r.MessagesPerSec, r.P50Ms, r.P90Ms, r.P99Ms)
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestChain(t *testing.T) {
+	for _, keyType := range []string{"ecdsa", "rsa"} {
+		t.Run(keyType, func(t *testing.T) {
+			ca, err := newCA("greet-ca", keyType, 24*time.Hour)
+			if err != nil {
+				t.Fatalf("newCA: %v", err)
+			}
+			if !ca.Cert.IsCA {
+				t.Error("CA certificate is not marked as a CA")
+			}
+			server, err := newLeaf(ca, "localhost", []string{"localhost"}, []net.IP{net.ParseIP("127.0.0.1")}, x509.ExtKeyUsageServerAuth, keyType, time.Hour)
+			if err != nil {
+				t.Fatalf("newLeaf: %v", err)
+			}
+			if err := verify(ca, server, x509.ExtKeyUsageServerAuth); err != nil {
+				t.Errorf("server certificate: %v", err)
+			}
+			if err := server.Cert.VerifyHostname("127.0.0.1"); err != nil {
+				t.Errorf("server certificate: %v", err)
+			}
+			if err := verify(ca, server, x509.ExtKeyUsageClientAuth); err == nil {
+				t.Error("server certificate verifies for client auth")
+			}
+
+			client, err := newLeaf(ca, "greet_client", nil, nil, x509.ExtKeyUsageClientAuth, keyType, time.Hour)
+			if err != nil {
+				t.Fatalf("newLeaf: %v", err)
+			}
+			if err := verify(ca, client, x509.ExtKeyUsageClientAuth); err != nil {
+				t.Errorf("client certificate: %v", err)
+			}
+
+			other, err := newCA("other-ca", keyType, time.Hour)
+			if err != nil {
+				t.Fatalf("newCA: %v", err)
+			}
+			if err := verify(other, server, x509.ExtKeyUsageServerAuth); err == nil {
+				t.Error("server certificate verifies against another CA")
+			}
+		})
+	}
+}
+
+func TestWriteLoadPair(t *testing.T) {
+	dir := t.TempDir()
+	ca, err := newCA("greet-ca", "ecdsa", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile, keyFile := filepath.Join(dir, "ca.crt"), filepath.Join(dir, "ca.key")
+	if err := writePair(ca, certFile, keyFile); err != nil {
+		t.Fatalf("writePair: %v", err)
+	}
+	loaded, err := loadPair(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("loadPair: %v", err)
+	}
+	if !loaded.Cert.Equal(ca.Cert) {
+		t.Error("loaded certificate differs from the written one")
+	}
+
+	// A reused CA must be able to sign new leaves that still chain up.
+	server, err := newLeaf(loaded, "localhost", []string{"localhost"}, nil, x509.ExtKeyUsageServerAuth, "ecdsa", time.Hour)
+	if err != nil {
+		t.Fatalf("newLeaf with the loaded CA: %v", err)
+	}
+	if err := verify(ca, server, x509.ExtKeyUsageServerAuth); err != nil {
+		t.Errorf("leaf of the loaded CA: %v", err)
+	}
+	if err := writePair(server, filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.pem")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tls.LoadX509KeyPair(filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.pem")); err != nil {
+		t.Errorf("written server pair does not load with crypto/tls: %v", err)
+	}
+}
+
+// TestCommittedCerts checks the files in ssl/, which instruction.sh reuses
+// the CA from and greet_server and greet_client load.
+func TestCommittedCerts(t *testing.T) {
+	dir := filepath.Join("..", "ssl")
+	path := func(name string) string { return filepath.Join(dir, name) }
+	ca, err := loadPair(path("ca.crt"), path("ca.key"))
+	if err != nil {
+		t.Fatalf("ssl/ca.key cannot be reused by instruction.sh: %v", err)
+	}
+	for _, tc := range []struct {
+		name  string
+		usage x509.ExtKeyUsage
+	}{
+		{"server", x509.ExtKeyUsageServerAuth},
+		{"client", x509.ExtKeyUsageClientAuth},
+	} {
+		pair, err := tls.LoadX509KeyPair(path(tc.name+".crt"), path(tc.name+".pem"))
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		leaf, err := x509.ParseCertificate(pair.Certificate[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The committed files expire, so check the chain as of the middle
+		// of the leaf's validity rather than today.
+		roots := x509.NewCertPool()
+		roots.AddCert(ca.Cert)
+		if _, err := leaf.Verify(x509.VerifyOptions{
+			Roots:       roots,
+			KeyUsages:   []x509.ExtKeyUsage{tc.usage},
+			CurrentTime: leaf.NotBefore.Add(leaf.NotAfter.Sub(leaf.NotBefore) / 2),
+		}); err != nil {
+			t.Errorf("%s certificate does not chain to ssl/ca.crt: %v", tc.name, err)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"crypto/x509"
+	"flag"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+func main() {
+	out := flag.String("out", "ssl", "directory to write the PEM files to")
+	caCN := flag.String("ca-cn", "greet-ca", "common name of the certificate authority")
+	serverCN := flag.String("cn", "localhost", "common name of the server certificate")
+	dns := flag.String("dns", "localhost", "comma separated DNS names for the server certificate")
+	ips := flag.String("ip", "127.0.0.1,::1", "comma separated IP addresses for the server certificate")
+	clientCN := flag.String("client-cn", "greet_client", "common name of the client certificate (empty skips it)")
+	keyType := flag.String("key-type", "ecdsa", "key algorithm: ecdsa (P-256) or rsa (4096 bit)")
+	days := flag.Int("days", 365, "validity of the server and client certificates in days")
+	caDays := flag.Int("ca-days", 3650, "validity of a newly created CA in days")
+	newCAFlag := flag.Bool("new-ca", false, "create a new CA even if ca.crt and ca.key already exist")
+	flag.Parse()
+
+	var ipAddrs []net.IP
+	for _, s := range splitList(*ips) {
+		ip := net.ParseIP(s)
+		if ip == nil {
+			log.Fatalf("Invalid IP address %q", s)
+		}
+		ipAddrs = append(ipAddrs, ip)
+	}
+	if err := os.MkdirAll(*out, 0755); err != nil {
+		log.Fatalln(err)
+	}
+	path := func(name string) string { return filepath.Join(*out, name) }
+	validFor := time.Duration(*days) * 24 * time.Hour
+
+	// ca.crt is shared with clients; ca.key must stay private.
+	var ca *keyPair
+	var err error
+	if !*newCAFlag && exists(path("ca.crt")) && exists(path("ca.key")) {
+		if ca, err = loadPair(path("ca.crt"), path("ca.key")); err != nil {
+			log.Fatalf("Loading existing CA : %v (use -new-ca to replace it)", err)
+		}
+		log.Printf("Reusing CA %v", ca.Cert.Subject)
+	} else {
+		if ca, err = newCA(*caCN, *keyType, time.Duration(*caDays)*24*time.Hour); err != nil {
+			log.Fatalln(err)
+		}
+		if err := writePair(ca, path("ca.crt"), path("ca.key")); err != nil {
+			log.Fatalln(err)
+		}
+		log.Printf("Wrote %s and %s", path("ca.crt"), path("ca.key"))
+	}
+
+	// server.crt and server.pem are what greet_server loads.
+	server, err := newLeaf(ca, *serverCN, splitList(*dns), ipAddrs, x509.ExtKeyUsageServerAuth, *keyType, validFor)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if err := verify(ca, server, x509.ExtKeyUsageServerAuth); err != nil {
+		log.Fatalf("Server certificate does not verify : %v", err)
+	}
+	if err := writePair(server, path("server.crt"), path("server.pem")); err != nil {
+		log.Fatalln(err)
+	}
+	log.Printf("Wrote %s and %s (DNS %v, IP %v, expires %v)", path("server.crt"), path("server.pem"), server.Cert.DNSNames, server.Cert.IPAddresses, server.Cert.NotAfter)
+
+	// client.crt and client.pem let greet_client authenticate with mTLS.
+	if *clientCN != "" {
+		client, err := newLeaf(ca, *clientCN, nil, nil, x509.ExtKeyUsageClientAuth, *keyType, validFor)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		if err := verify(ca, client, x509.ExtKeyUsageClientAuth); err != nil {
+			log.Fatalf("Client certificate does not verify : %v", err)
+		}
+		if err := writePair(client, path("client.crt"), path("client.pem")); err != nil {
+			log.Fatalln(err)
+		}
+		log.Printf("Wrote %s and %s (expires %v)", path("client.crt"), path("client.pem"), client.Cert.NotAfter)
+	}
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func exists(name string) bool {
+	_, err := os.Stat(name)
+	return err == nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/resolver/manual"
+)
+
+// leastRequestName is the balancer that sends each RPC to the ready backend
+// with the fewest RPCs in flight, rotating between backends that tie.
+const leastRequestName = "greet_least_request"
+
+func init() {
+	balancer.Register(base.NewBalancerBuilder(leastRequestName, leastRequestPickerBuilder{}, base.Config{HealthCheck: true}))
+}
+
+type leastRequestPickerBuilder struct{}
+
+func (leastRequestPickerBuilder) Build(info base.PickerBuildInfo) balancer.Picker {
+	if len(info.ReadySCs) == 0 {
+		return base.NewErrPicker(balancer.ErrNoSubConnAvailable)
+	}
+	p := &leastRequestPicker{}
+	for sc := range info.ReadySCs {
+		p.subConns = append(p.subConns, sc)
+	}
+	p.inflight = make([]int64, len(p.subConns))
+	return p
+}
+
+type leastRequestPicker struct {
+	subConns []balancer.SubConn
+	inflight []int64
+
+	mu   sync.Mutex
+	next int
+}
+
+func (p *leastRequestPicker) Pick(balancer.PickInfo) (balancer.PickResult, error) {
+	p.mu.Lock()
+	n := len(p.subConns)
+	best := p.next
+	for i := 1; i < n; i++ {
+		j := (p.next + i) % n
+		if atomic.LoadInt64(&p.inflight[j]) < atomic.LoadInt64(&p.inflight[best]) {
+			best = j
+		}
+	}
+	p.next = (best + 1) % n
+	p.mu.Unlock()
+
+	atomic.AddInt64(&p.inflight[best], 1)
+	return balancer.PickResult{
+		SubConn: p.subConns[best],
+		Done: func(balancer.DoneInfo) {
+			atomic.AddInt64(&p.inflight[best], -1)
+		},
+	}, nil
+}
+
+// staticScheme is the resolver scheme used for a fixed list of addresses.
+const staticScheme = "greet-static"
+
+// newStaticResolver returns a resolver that always reports addrs. Tests can
+// call UpdateState on it to add or remove backends.
+func newStaticResolver(addrs []string) *manual.Resolver {
+	r := manual.NewBuilderWithScheme(staticScheme)
+	state := resolver.State{}
+	for _, addr := range addrs {
+		state.Addresses = append(state.Addresses, resolver.Address{Addr: addr})
+	}
+	r.InitialState(state)
+	return r
+}
+
+// dialTarget turns the -addr flag into a gRPC target. A comma separated
+// list of addresses is served by a static resolver; a single value is
+// passed through unchanged, so "dns:///greet.example.com:50051" resolves
+// every A record of the name.
+func dialTarget(addr string) (string, []grpc.DialOption) {
+	var addrs []string
+	for _, a := range strings.Split(addr, ",") {
+		if a = strings.TrimSpace(a); a != "" {
+			addrs = append(addrs, a)
+		}
+	}
+	if len(addrs) <= 1 {
+		return addr, nil
+	}
+	r := newStaticResolver(addrs)
+	return staticScheme + ":///greet", []grpc.DialOption{grpc.WithResolvers(r)}
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"../greetpb"
+	"../greetpb/greetmock"
+	"google.golang.org/grpc"
+)
+
+// backend is an in-process greet_server on a local port that counts the
+// Greet calls it answers. A greeting for "slow" is held until release is
+// closed.
+type backend struct {
+	addr    string
+	srv     *grpc.Server
+	calls   int64
+	slow    int64
+	release chan struct{}
+}
+
+func startBackends(t *testing.T, n int) []*backend {
+	t.Helper()
+	release := make(chan struct{})
+	backends := make([]*backend, n)
+	for i := range backends {
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		b := &backend{addr: lis.Addr().String(), srv: grpc.NewServer(), release: release}
+		greetpb.RegisterGreetServiceServer(b.srv, &greetmock.Server{
+			GreetFunc: func(ctx context.Context, req *greetpb.GreetRequest) (*greetpb.GreetResponse, error) {
+				if req.GetGreeting().GetFirstName() == "slow" {
+					atomic.AddInt64(&b.slow, 1)
+					select {
+					case <-b.release:
+					case <-ctx.Done():
+					}
+				} else {
+					atomic.AddInt64(&b.calls, 1)
+				}
+				return &greetpb.GreetResponse{Result: b.addr}, nil
+			},
+		})
+		go b.srv.Serve(lis)
+		backends[i] = b
+	}
+	t.Cleanup(func() {
+		close(release)
+		for _, b := range backends {
+			b.srv.Stop()
+		}
+	})
+	return backends
+}
+
+// dialBackends dials all backends through dialTarget with the given
+// balancer, and waits until every one of them has answered a call.
+func dialBackends(t *testing.T, backends []*backend, lb string) greetpb.GreetServiceClient {
+	t.Helper()
+	addrs := make([]string, len(backends))
+	for i, b := range backends {
+		addrs[i] = b.addr
+	}
+	target, opts := dialTarget(strings.Join(addrs, ","))
+	p := testPolicy
+	p.Balancer = lb
+	opts = append(opts, grpc.WithInsecure(), grpc.WithDefaultServiceConfig(serviceConfig(p)))
+	conn, err := grpc.Dial(target, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	client := greetpb.NewGreetServiceClient(conn)
+
+	seen := map[string]bool{}
+	deadline := time.Now().Add(5 * time.Second)
+	for len(seen) < len(backends) {
+		if time.Now().After(deadline) {
+			t.Fatalf("only %d of %d backends reached", len(seen), len(backends))
+		}
+		res, err := client.Greet(context.Background(), &greetpb.GreetRequest{Greeting: &greetpb.Greeting{FirstName: "warmup"}})
+		if err != nil {
+			t.Fatalf("Greet: %v", err)
+		}
+		seen[res.GetResult()] = true
+	}
+	for _, b := range backends {
+		atomic.StoreInt64(&b.calls, 0)
+	}
+	return client
+}
+
+func greetN(t *testing.T, client greetpb.GreetServiceClient, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		if _, err := client.Greet(context.Background(), &greetpb.GreetRequest{Greeting: &greetpb.Greeting{FirstName: fmt.Sprint(i)}}); err != nil {
+			t.Fatalf("Greet %d: %v", i, err)
+		}
+	}
+}
+
+func callCounts(backends []*backend) []int64 {
+	counts := make([]int64, len(backends))
+	for i, b := range backends {
+		counts[i] = atomic.LoadInt64(&b.calls)
+	}
+	return counts
+}
+
+func TestBalancerSpreadsCalls(t *testing.T) {
+	for _, lb := range []string{"round_robin", leastRequestName} {
+		t.Run(lb, func(t *testing.T) {
+			backends := startBackends(t, 3)
+			client := dialBackends(t, backends, lb)
+			greetN(t, client, 30)
+			for i, n := range callCounts(backends) {
+				if n != 10 {
+					t.Errorf("backend %d answered %d of 30 sequential calls, want 10: %v", i, n, callCounts(backends))
+				}
+			}
+		})
+	}
+}
+
+func TestLeastRequestAvoidsBusyBackends(t *testing.T) {
+	backends := startBackends(t, 3)
+	client := dialBackends(t, backends, leastRequestName)
+
+	// Two calls held open occupy two backends...
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	for i := 0; i < 2; i++ {
+		go client.Greet(ctx, &greetpb.GreetRequest{Greeting: &greetpb.Greeting{FirstName: "slow"}})
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	busy := func() (n int) {
+		for _, b := range backends {
+			if atomic.LoadInt64(&b.slow) > 0 {
+				n++
+			}
+		}
+		return n
+	}
+	for busy() < 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("slow calls reached %d backends, want 2", busy())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// ...so every other call goes to the idle one.
+	greetN(t, client, 10)
+	for i, b := range backends {
+		slow, want := atomic.LoadInt64(&b.slow), int64(0)
+		if slow == 0 {
+			want = 10
+		}
+		if n := atomic.LoadInt64(&b.calls); n != want {
+			t.Errorf("backend %d (slow calls %d) answered %d calls, want %d", i, slow, n, want)
+		}
+	}
+}
+
+func TestBalancerFailover(t *testing.T) {
+	for _, lb := range []string{"round_robin", leastRequestName} {
+		t.Run(lb, func(t *testing.T) {
+			backends := startBackends(t, 3)
+			client := dialBackends(t, backends, lb)
+
+			backends[0].srv.Stop()
+			// Calls picked before the balancer notices are retried on
+			// UNAVAILABLE, so none of them fail.
+			greetN(t, client, 30)
+			counts := callCounts(backends)
+			if counts[0] != 0 {
+				t.Errorf("stopped backend answered %d calls", counts[0])
+			}
+			for i, n := range counts[1:] {
+				if n < 10 {
+					t.Errorf("backend %d answered %d of 30 calls, want them shared between the two left: %v", i+1, n, counts)
+				}
+			}
+		})
+	}
+}
@@ -2,23 +2,71 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"flag"
 	"io"
 	"log"
 	"time"
 
 	"../greetpb"
+	"github.com/golang/protobuf/ptypes"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/metadata"
 )
 
 func main() {
+	addr := flag.String("addr", "localhost:50051", "greet_server address, comma separated addresses, or a target such as dns:///host:port or greet:///greet_server")
+	registryFile := flag.String("registry", "registry.yaml", "service registry file used to resolve greet:/// targets")
+	registryInterval := flag.Duration("registry-interval", 5*time.Second, "how often to check the registry file for changes (0 disables polling)")
+	lb := flag.String("lb", "round_robin", "load balancing policy: pick_first, round_robin or "+leastRequestName)
+	keepaliveTime := flag.Duration("keepalive-time", 5*time.Minute, "ping the server after this long without activity (0 disables pings)")
+	keepaliveTimeout := flag.Duration("keepalive-timeout", 20*time.Second, "close the connection if a ping is not answered in time")
+	keepalivePermit := flag.Bool("keepalive-permit-without-stream", false, "ping even when no RPC is active (the server must allow it)")
+	greetTimeout := flag.Duration("greet-timeout", 5*time.Second, "deadline for each unary call, across all Greet retries")
+	streamTimeout := flag.Duration("stream-timeout", time.Minute, "deadline for GreetManyTimes and LongGreet (GreetEveryOne and SubscribeGreetings have none)")
+	retryAttempts := flag.Int("retry-attempts", 4, "total Greet attempts when the server is UNAVAILABLE (1 disables retries)")
+	retryBackoff := flag.Duration("retry-backoff", 100*time.Millisecond, "backoff before the first Greet retry, doubled for each further retry")
+	retryMaxBackoff := flag.Duration("retry-max-backoff", time.Second, "upper bound on the Greet retry backoff")
+	hedge := flag.Bool("hedge", false, "hedge Greet calls instead of retrying them")
+	hedgeDelay := flag.Duration("hedge-delay", 200*time.Millisecond, "wait this long for an answer before sending a hedged Greet")
+	hedgeAttempts := flag.Int("hedge-attempts", 3, "maximum Greet copies in flight when hedging")
+	flag.Parse()
 
 	creds, err := credentials.NewClientTLSFromFile("greet/ssl/server.crt", "")
 	if err != nil {
 		log.Fatalln(err)
 	}
 	_ = grpc.WithTransportCredentials(creds)
-	client, err := grpc.Dial("localhost:50051", grpc.WithInsecure())
+	opts := []grpc.DialOption{grpc.WithInsecure()}
+	if *keepaliveTime > 0 {
+		// The server's keepalive min_time must not exceed keepalive-time,
+		// otherwise it answers the pings with GOAWAY.
+		opts = append(opts, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                *keepaliveTime,
+			Timeout:             *keepaliveTimeout,
+			PermitWithoutStream: *keepalivePermit,
+		}))
+	}
+	policy := callPolicy{
+		Balancer:       *lb,
+		GreetTimeout:   *greetTimeout,
+		StreamTimeout:  *streamTimeout,
+		RetryAttempts:  *retryAttempts,
+		InitialBackoff: *retryBackoff,
+		MaxBackoff:     *retryMaxBackoff,
+	}
+	if *hedge {
+		policy.RetryAttempts = 1
+		opts = append(opts, grpc.WithUnaryInterceptor(hedgingInterceptor(*hedgeDelay, *hedgeAttempts)))
+	}
+	opts = append(opts, grpc.WithDefaultServiceConfig(serviceConfig(policy)))
+	opts = append(opts, grpc.WithResolvers(newRegistryBuilder(*registryFile, *registryInterval)))
+	target, targetOpts := dialTarget(*addr)
+	client, err := grpc.Dial(target, append(opts, targetOpts...)...)
 	if err != nil {
 		log.Fatalln(err)
 	}
@@ -27,11 +75,12 @@ func main() {
 	// Greetings
 	conn := greetpb.NewGreetServiceClient(client)
 	unaryGreet(conn)
-	//serverStreamGreet(conn)
-	//serverStreamPrimeNumberDecomposition(conn)
+	//serverStreamGreet(conn, *retryBackoff, *retryMaxBackoff)
 	//clienStreamLongGreet(conn)
-	//doGreetEveryOne(conn)
-	//doFindMaximum(conn)
+	//doGreetEveryOne(conn, *retryBackoff, *retryMaxBackoff)
+	//subscribeGreetings(conn)
+	//listGreetings(conn)
+	//greetBatch(conn)
 
 }
 
@@ -44,14 +93,26 @@ func unaryGreet(client greetpb.GreetServiceClient) {
 		},
 	}
 
-	res, err := client.Greet(context.Background(), req)
+	// Retries and hedged attempts carry the same key, so the server only
+	// greets once.
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "idempotency-key", newIdempotencyKey())
+	res, err := client.Greet(ctx, req)
 	if err != nil {
 		log.Fatalf("Erro while calling Greet RPC : %v", err)
 	}
 	log.Printf("Response from Greet : %v", res)
 }
 
-func serverStreamGreet(client greetpb.GreetServiceClient) {
+// newIdempotencyKey returns a random key identifying one logical Greet call.
+func newIdempotencyKey() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		log.Fatalf("Error while generating idempotency key : %v", err)
+	}
+	return hex.EncodeToString(b)
+}
+
+func serverStreamGreet(client greetpb.GreetServiceClient, backoff, maxBackoff time.Duration) {
 	log.Println("Staring to do server streaming RPC..!!")
 	req := &greetpb.GreetRequest{
 		Greeting: &greetpb.Greeting{
@@ -60,10 +121,7 @@ func serverStreamGreet(client greetpb.GreetServiceClient) {
 		},
 	}
 
-	resStream, err := client.GreetManyTimes(context.Background(), req)
-	if err != nil {
-		log.Fatalf("Error while calling GreetManyTimes RPC : %v", err)
-	}
+	resStream := newManyTimesStream(context.Background(), client, req, backoff, maxBackoff)
 	for {
 		res, err := resStream.Recv()
 		if err == io.EOF {
@@ -129,7 +187,7 @@ func clienStreamLongGreet(client greetpb.GreetServiceClient) {
 	log.Printf("Response from Longgreet : %v", res)
 }
 
-func doGreetEveryOne(client greetpb.GreetServiceClient) {
+func doGreetEveryOne(client greetpb.GreetServiceClient, backoff, maxBackoff time.Duration) {
 	log.Print("Starting to do client and streaming RPC..!!")
 
 	reqStream := []*greetpb.GreetRequest{
@@ -163,10 +221,7 @@ func doGreetEveryOne(client greetpb.GreetServiceClient) {
 			},
 		},
 	}
-	stream, err := client.GreetEveryOne(context.Background())
-	if err != nil {
-		log.Fatalf("Error while calling GreetEveryOne function : %v", err)
-	}
+	stream := newEveryOneStream(context.Background(), client, backoff, maxBackoff)
 	waitc := make(chan struct{})
 
 	go func() {
@@ -195,45 +250,64 @@ func doGreetEveryOne(client greetpb.GreetServiceClient) {
 	<-waitc
 }
 
-func doFindMaximum(client greetpb.GreetServiceClient) {
-	log.Print("Starting to do client and streaming RPC..!!")
+func subscribeGreetings(client greetpb.GreetServiceClient) {
+	log.Print("Starting to watch greetings..!!")
+	req := &greetpb.SubscribeGreetingsRequest{FirstNamePrefix: "Nanda"}
 
-	reqStream := []*greetpb.Numbers{
-		&greetpb.Numbers{Number: 1},
-		&greetpb.Numbers{Number: 5},
-		&greetpb.Numbers{Number: 3},
-		&greetpb.Numbers{Number: 6},
-		&greetpb.Numbers{Number: 2},
-		&greetpb.Numbers{Number: 20},
-	}
-	stream, err := client.FindMaximum(context.Background())
+	stream, err := client.SubscribeGreetings(context.Background(), req)
 	if err != nil {
-		log.Fatalf("Error while calling FindMaximum function : %v", err)
+		log.Fatalf("Error while calling SubscribeGreetings RPC : %v", err)
 	}
-	waitc := make(chan struct{})
-
-	go func() {
-		for _, req := range reqStream {
-			log.Printf("Sending request : %v", req)
-			_ = stream.Send(req)
-			time.Sleep(1000 * time.Millisecond)
+	for {
+		ev, err := stream.Recv()
+		if err == io.EOF {
+			break
 		}
-		_ = stream.CloseSend()
-	}()
+		if err != nil {
+			log.Fatalf("Error while receiving greeting events : %v", err)
+		}
+		log.Printf("Greeting event : %v", ev)
+	}
+}
 
-	go func() {
-		for {
-			res, err := stream.Recv()
-			if err == io.EOF {
-				break
-			}
-			if err != nil {
-				log.Fatalf("Error while Receiving : %v", err)
-			}
-			log.Printf("Response : %v", res.GetNumber())
+func listGreetings(client greetpb.GreetServiceClient) {
+	log.Print("Listing greetings of the last hour..!!")
+	start, _ := ptypes.TimestampProto(time.Now().Add(-time.Hour))
+	req := &greetpb.ListGreetingsRequest{PageSize: 20, StartTime: start}
+	for {
+		res, err := client.ListGreetings(context.Background(), req)
+		if err != nil {
+			log.Fatalf("Error while calling ListGreetings RPC : %v", err)
 		}
-		close(waitc)
-	}()
+		for _, rec := range res.GetGreetings() {
+			log.Printf("Greeting : %v", rec)
+		}
+		if res.GetNextPageToken() == "" {
+			break
+		}
+		req.PageToken = res.GetNextPageToken()
+	}
+}
 
-	<-waitc
+func greetBatch(client greetpb.GreetServiceClient) {
+	log.Print("Starting to do a batch RPC..!!")
+	req := &greetpb.GreetBatchRequest{
+		Greetings: []*greetpb.Greeting{
+			&greetpb.Greeting{FirstName: "Nandakumar", LastName: "R"},
+			&greetpb.Greeting{FirstName: "Stephane", LastName: "Maarek"},
+			&greetpb.Greeting{},
+		},
+	}
+
+	res, err := client.GreetBatch(context.Background(), req)
+	if err != nil {
+		log.Fatalf("Error while calling GreetBatch RPC : %v", err)
+	}
+	for i, r := range res.GetResults() {
+		if codes.Code(r.GetCode()) != codes.OK {
+			log.Printf("Greeting %d failed : %v %s", i, codes.Code(r.GetCode()), r.GetMessage())
+			continue
+		}
+		log.Printf("Response from GreetBatch : %v", r.GetResult())
+	}
 }
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"../greetpb"
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// everyOneStream is a GreetEveryOne stream that survives connection loss.
+// Requests are numbered with sequence_id and kept until the server
+// acknowledges them; when the stream breaks with UNAVAILABLE it is reopened
+// with exponential backoff and the unacknowledged requests are sent again.
+//
+// Delivery is at least once: a request whose response was lost in the
+// break is processed by the server again, but the duplicate response is
+// dropped, so Recv returns each answer once.
+type everyOneStream struct {
+	client     greetpb.GreetServiceClient
+	ctx        context.Context
+	backoff    time.Duration
+	maxBackoff time.Duration
+
+	// sendMu serialises the Send and CloseSend calls on stream, keeping
+	// requests in sequence order. It is taken before mu and held while a
+	// Send blocks, so the receiving side, which only needs mu, keeps
+	// draining responses.
+	sendMu sync.Mutex
+
+	mu      sync.Mutex
+	stream  greetpb.GreetService_GreetEveryOneClient
+	nextSeq uint64
+	acked   uint64
+	pending []*greetpb.GreetRequest
+	closed  bool
+
+	responses chan *greetpb.GreetResponse
+	err       error
+}
+
+func newEveryOneStream(ctx context.Context, client greetpb.GreetServiceClient, backoff, maxBackoff time.Duration) *everyOneStream {
+	s := &everyOneStream{
+		client:     client,
+		ctx:        ctx,
+		backoff:    backoff,
+		maxBackoff: maxBackoff,
+		nextSeq:    1,
+		responses:  make(chan *greetpb.GreetResponse),
+	}
+	go s.run()
+	return s
+}
+
+// Send queues req for delivery. It only fails once the stream has ended for
+// good; a broken connection is handled by the reconnect loop.
+func (s *everyOneStream) Send(req *greetpb.GreetRequest) error {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return status.Error(codes.FailedPrecondition, "send after CloseSend")
+	}
+	if err := s.ctx.Err(); err != nil {
+		s.mu.Unlock()
+		return status.FromContextError(err).Err()
+	}
+	req.SequenceId = s.nextSeq
+	s.nextSeq++
+	s.pending = append(s.pending, req)
+	stream := s.stream
+	s.mu.Unlock()
+	if stream != nil {
+		// An error means the stream is broken; run reopens it and resends.
+		_ = stream.Send(req)
+	}
+	return nil
+}
+
+// CloseSend tells the server no more requests follow. The stream ends once
+// every pending request has been answered.
+func (s *everyOneStream) CloseSend() error {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+	s.mu.Lock()
+	s.closed = true
+	stream := s.stream
+	s.mu.Unlock()
+	if stream != nil {
+		return stream.CloseSend()
+	}
+	return nil
+}
+
+// Recv returns the next response, io.EOF when the server finished the
+// stream, or the error that made reconnecting pointless.
+func (s *everyOneStream) Recv() (*greetpb.GreetResponse, error) {
+	res, ok := <-s.responses
+	if !ok {
+		return nil, s.err
+	}
+	return res, nil
+}
+
+func (s *everyOneStream) run() {
+	defer close(s.responses)
+	backoff := s.backoff
+	for {
+		err := s.attempt(&backoff)
+		if err == io.EOF || status.Code(err) != codes.Unavailable || s.ctx.Err() != nil {
+			if s.ctx.Err() != nil {
+				err = status.FromContextError(s.ctx.Err()).Err()
+			}
+			s.err = err
+			return
+		}
+		log.Printf("GreetEveryOne stream broken, reconnecting in %v : %v", backoff, err)
+		select {
+		case <-time.After(backoff):
+		case <-s.ctx.Done():
+		}
+		if backoff *= 2; backoff > s.maxBackoff {
+			backoff = s.maxBackoff
+		}
+	}
+}
+
+// attempt opens one stream, resends what is pending and reads responses
+// until the stream ends. backoff is reset once the server answers.
+func (s *everyOneStream) attempt(backoff *time.Duration) error {
+	stream, err := s.client.GreetEveryOne(s.ctx)
+	if err != nil {
+		return err
+	}
+	// Resending runs alongside the receive loop below: with many requests
+	// pending, the server may only read more once its answers are taken.
+	s.sendMu.Lock()
+	s.mu.Lock()
+	pending := append([]*greetpb.GreetRequest(nil), s.pending...)
+	closed := s.closed
+	s.stream = stream
+	s.mu.Unlock()
+	go func() {
+		defer s.sendMu.Unlock()
+		for _, req := range pending {
+			if err := stream.Send(req); err != nil {
+				return
+			}
+		}
+		if closed {
+			_ = stream.CloseSend()
+		}
+	}()
+
+	for {
+		res, err := stream.Recv()
+		if err != nil {
+			s.mu.Lock()
+			s.stream = nil
+			s.mu.Unlock()
+			return err
+		}
+		*backoff = s.backoff
+		if !s.ack(res.GetAckSequenceId()) {
+			continue
+		}
+		select {
+		case s.responses <- res:
+		case <-s.ctx.Done():
+			return s.ctx.Err()
+		}
+	}
+}
+
+// ack drops the requests up to seq from the pending list. It reports false
+// for a response to a request that was already acknowledged.
+func (s *everyOneStream) ack(seq uint64) bool {
+	if seq == 0 {
+		// Not a sequenced response; nothing to acknowledge.
+		return true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if seq <= s.acked {
+		return false
+	}
+	s.acked = seq
+	i := 0
+	for i < len(s.pending) && s.pending[i].GetSequenceId() <= seq {
+		i++
+	}
+	s.pending = s.pending[i:]
+	return true
+}
+
+// manyTimesStream is a GreetManyTimes stream that resumes where it broke
+// off. It remembers the resume token of the last message received and, when
+// the stream fails with UNAVAILABLE, calls GreetManyTimes again with that
+// token after an exponential backoff.
+type manyTimesStream struct {
+	client     greetpb.GreetServiceClient
+	ctx        context.Context
+	req        *greetpb.GreetRequest
+	backoff    time.Duration
+	maxBackoff time.Duration
+
+	stream greetpb.GreetService_GreetManyTimesClient
+	token  string
+}
+
+func newManyTimesStream(ctx context.Context, client greetpb.GreetServiceClient, req *greetpb.GreetRequest, backoff, maxBackoff time.Duration) *manyTimesStream {
+	return &manyTimesStream{
+		client:     client,
+		ctx:        ctx,
+		req:        req,
+		backoff:    backoff,
+		maxBackoff: maxBackoff,
+		token:      req.GetResumeToken(),
+	}
+}
+
+// Recv returns the next response, io.EOF at the end of the stream, or the
+// first error that resuming cannot fix.
+func (s *manyTimesStream) Recv() (*greetpb.GreetResponse, error) {
+	backoff := s.backoff
+	for {
+		var err error
+		if s.stream == nil {
+			req := proto.Clone(s.req).(*greetpb.GreetRequest)
+			req.ResumeToken = s.token
+			s.stream, err = s.client.GreetManyTimes(s.ctx, req)
+		}
+		if err == nil {
+			var res *greetpb.GreetResponse
+			if res, err = s.stream.Recv(); err == nil {
+				if token := res.GetResumeToken(); token != "" {
+					s.token = token
+				}
+				return res, nil
+			}
+		}
+		s.stream = nil
+		if err == io.EOF || status.Code(err) != codes.Unavailable || s.ctx.Err() != nil {
+			return nil, err
+		}
+		log.Printf("GreetManyTimes stream broken, resuming in %v : %v", backoff, err)
+		select {
+		case <-time.After(backoff):
+		case <-s.ctx.Done():
+			return nil, status.FromContextError(s.ctx.Err()).Err()
+		}
+		if backoff *= 2; backoff > s.maxBackoff {
+			backoff = s.maxBackoff
+		}
+	}
+}
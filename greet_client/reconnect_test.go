@@ -0,0 +1,329 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"../greetpb"
+	"../greetserver"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// window is the fixed HTTP/2 flow control window of the servers and clients
+// below. Without it gRPC grows the windows to megabytes and a test would
+// need that much data in flight before a Send blocks.
+const window = 64 << 10
+
+// restartableServer is a greetserver.Server on a fixed local address that
+// can be killed and brought back.
+type restartableServer struct {
+	t    *testing.T
+	addr string
+	impl greetpb.GreetServiceServer
+	srv  *grpc.Server
+}
+
+func startRestartable(t *testing.T, impl greetpb.GreetServiceServer) *restartableServer {
+	t.Helper()
+	rs := &restartableServer{t: t, addr: "127.0.0.1:0", impl: impl}
+	rs.start()
+	t.Cleanup(rs.kill)
+	return rs
+}
+
+func (rs *restartableServer) start() {
+	rs.t.Helper()
+	var lis net.Listener
+	var err error
+	// The port of a killed server can take a moment to be released.
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		if lis, err = net.Listen("tcp", rs.addr); err == nil || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err != nil {
+		rs.t.Fatal(err)
+	}
+	rs.addr = lis.Addr().String()
+	rs.srv = grpc.NewServer(grpc.InitialWindowSize(window), grpc.InitialConnWindowSize(window))
+	greetpb.RegisterGreetServiceServer(rs.srv, rs.impl)
+	go rs.srv.Serve(lis)
+}
+
+// kill drops every connection and stream at once, like a crashed server.
+func (rs *restartableServer) kill() {
+	rs.srv.Stop()
+}
+
+func (rs *restartableServer) client(t *testing.T) greetpb.GreetServiceClient {
+	t.Helper()
+	conn, err := grpc.Dial(rs.addr, grpc.WithInsecure(),
+		grpc.WithInitialWindowSize(window), grpc.WithInitialConnWindowSize(window),
+		grpc.WithConnectParams(grpc.ConnectParams{
+			Backoff:           backoff.Config{BaseDelay: 10 * time.Millisecond, Multiplier: 1.6, MaxDelay: 100 * time.Millisecond},
+			MinConnectTimeout: time.Second,
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return greetpb.NewGreetServiceClient(conn)
+}
+
+func sendName(t *testing.T, stream *everyOneStream, name string) {
+	t.Helper()
+	if err := stream.Send(&greetpb.GreetRequest{Greeting: &greetpb.Greeting{FirstName: name}}); err != nil {
+		t.Fatalf("Send %s: %v", name, err)
+	}
+}
+
+func recvName(t *testing.T, stream *everyOneStream, name string) {
+	t.Helper()
+	res, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Recv for %s: %v", name, err)
+	}
+	if want := "Hello " + name + "! "; res.GetResult() != want {
+		t.Fatalf("Result = %q, want %q", res.GetResult(), want)
+	}
+}
+
+func TestEveryOneStreamServerKilled(t *testing.T) {
+	rs := startRestartable(t, &greetserver.Server{})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	stream := newEveryOneStream(ctx, rs.client(t), 10*time.Millisecond, 100*time.Millisecond)
+
+	for _, name := range []string{"nanda", "kumar"} {
+		sendName(t, stream, name)
+		recvName(t, stream, name)
+	}
+
+	// Requests sent while the server is down are queued, not lost, and
+	// Send does not block or fail.
+	rs.kill()
+	down := []string{"stephane", "maarek", "grpc"}
+	for _, name := range down {
+		sendName(t, stream, name)
+	}
+	rs.start()
+	for _, name := range down {
+		recvName(t, stream, name)
+	}
+
+	// Killed again with a request in flight: it is answered exactly once.
+	sendName(t, stream, "again")
+	rs.kill()
+	rs.start()
+	recvName(t, stream, "again")
+
+	if err := stream.CloseSend(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stream.Recv(); err != io.EOF {
+		t.Fatalf("Recv after CloseSend = %v, want io.EOF", err)
+	}
+}
+
+// Sending blocks once flow control windows fill up. The receiving side must
+// keep acknowledging meanwhile, or neither side makes progress.
+func TestEveryOneStreamSendDoesNotBlockRecv(t *testing.T) {
+	rs := startRestartable(t, &greetserver.Server{QueueSize: 4, QueuePolicy: greetserver.QueueBlock})
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+	stream := newEveryOneStream(ctx, rs.client(t), 10*time.Millisecond, 100*time.Millisecond)
+
+	const n = 500
+	big := strings.Repeat("x", 16<<10)
+	sent := make(chan error, 1)
+	go func() {
+		for i := 0; i < n; i++ {
+			req := &greetpb.GreetRequest{Greeting: &greetpb.Greeting{FirstName: fmt.Sprint(i, big)}}
+			if err := stream.Send(req); err != nil {
+				sent <- err
+				return
+			}
+		}
+		sent <- stream.CloseSend()
+	}()
+	for i := 0; i < n; i++ {
+		res, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("Recv %d: %v", i, err)
+		}
+		if res.GetAckSequenceId() != uint64(i+1) {
+			t.Fatalf("response %d acknowledges %d", i, res.GetAckSequenceId())
+		}
+	}
+	if err := <-sent; err != nil {
+		t.Fatalf("sending: %v", err)
+	}
+	if _, err := stream.Recv(); err != io.EOF {
+		t.Fatalf("Recv after CloseSend = %v, want io.EOF", err)
+	}
+}
+
+// Killing the server while a large backlog is resent must not wedge the
+// stream either.
+func TestEveryOneStreamResendBacklog(t *testing.T) {
+	rs := startRestartable(t, &greetserver.Server{QueueSize: 4, QueuePolicy: greetserver.QueueBlock})
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+	stream := newEveryOneStream(ctx, rs.client(t), 10*time.Millisecond, 100*time.Millisecond)
+	sendName(t, stream, "first")
+	recvName(t, stream, "first")
+
+	rs.kill()
+	const n = 200
+	big := strings.Repeat("x", 16<<10)
+	for i := 0; i < n; i++ {
+		if err := stream.Send(&greetpb.GreetRequest{Greeting: &greetpb.Greeting{FirstName: fmt.Sprint(i, big)}}); err != nil {
+			t.Fatalf("Send %d: %v", i, err)
+		}
+	}
+	rs.start()
+	for i := 0; i < n; i++ {
+		res, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("Recv %d: %v", i, err)
+		}
+		if res.GetAckSequenceId() != uint64(i+2) {
+			t.Fatalf("response %d acknowledges %d, want %d", i, res.GetAckSequenceId(), i+2)
+		}
+	}
+}
+
+func greetManyTimes(t *testing.T, stream *manyTimesStream) []string {
+	t.Helper()
+	var got []string
+	for {
+		res, err := stream.Recv()
+		if err == io.EOF {
+			return got
+		}
+		if err != nil {
+			t.Fatalf("Recv after %d messages: %v", len(got), err)
+		}
+		got = append(got, res.GetResult())
+	}
+}
+
+func wantManyTimes(t *testing.T, got []string) {
+	t.Helper()
+	if len(got) != 10 {
+		t.Fatalf("got %d messages, want 10: %q", len(got), got)
+	}
+	for i, res := range got {
+		if want := fmt.Sprintf("Hello Nanda R : number = %d", i); res != want {
+			t.Errorf("message %d = %q, want %q", i, res, want)
+		}
+	}
+}
+
+// A transport failure in the middle of GreetManyTimes resumes after the
+// last message received: nothing is repeated and nothing is skipped.
+func TestManyTimesStreamServerKilled(t *testing.T) {
+	rs := startRestartable(t, &greetserver.Server{Interval: 20 * time.Millisecond})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	req := &greetpb.GreetRequest{Greeting: &greetpb.Greeting{FirstName: "Nanda", LastName: "R"}}
+	stream := newManyTimesStream(ctx, rs.client(t), req, 10*time.Millisecond, 100*time.Millisecond)
+
+	var got []string
+	for len(got) < 3 {
+		res, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("Recv %d: %v", len(got), err)
+		}
+		got = append(got, res.GetResult())
+	}
+	rs.kill()
+	go func() {
+		// Down for a few backoff rounds.
+		time.Sleep(100 * time.Millisecond)
+		rs.start()
+	}()
+	wantManyTimes(t, append(got, greetManyTimes(t, stream)...))
+	if req.GetResumeToken() != "" {
+		t.Errorf("caller's request modified: resume_token %q", req.GetResumeToken())
+	}
+}
+
+// flakyManyTimes serves GreetManyTimes but breaks every call with
+// UNAVAILABLE after it has sent after messages.
+type flakyManyTimes struct {
+	greetserver.Server
+	after int
+	calls int64
+}
+
+func (f *flakyManyTimes) GreetManyTimes(req *greetpb.GreetRequest, stream greetpb.GreetService_GreetManyTimesServer) error {
+	atomic.AddInt64(&f.calls, 1)
+	err := f.Server.GreetManyTimes(req, &breakingStream{stream, f.after})
+	if err == errBroken {
+		return status.Error(codes.Unavailable, "connection reset")
+	}
+	return err
+}
+
+var errBroken = errors.New("broken")
+
+type breakingStream struct {
+	greetpb.GreetService_GreetManyTimesServer
+	left int
+}
+
+func (s *breakingStream) Send(res *greetpb.GreetResponse) error {
+	if s.left == 0 {
+		return errBroken
+	}
+	s.left--
+	return s.GreetService_GreetManyTimesServer.Send(res)
+}
+
+func TestManyTimesStreamResumesRepeatedly(t *testing.T) {
+	impl := &flakyManyTimes{after: 3}
+	rs := startRestartable(t, impl)
+	req := &greetpb.GreetRequest{Greeting: &greetpb.Greeting{FirstName: "Nanda", LastName: "R"}}
+	stream := newManyTimesStream(context.Background(), rs.client(t), req, time.Millisecond, 10*time.Millisecond)
+	wantManyTimes(t, greetManyTimes(t, stream))
+	// 3 + 3 + 3 + 1 messages.
+	if calls := atomic.LoadInt64(&impl.calls); calls != 4 {
+		t.Errorf("%d GreetManyTimes calls, want 4", calls)
+	}
+}
+
+func TestManyTimesStreamDoesNotRetryOtherErrors(t *testing.T) {
+	rs := startRestartable(t, &greetserver.Server{})
+	req := &greetpb.GreetRequest{Greeting: &greetpb.Greeting{FirstName: "Nanda"}, ResumeToken: "not a token"}
+	stream := newManyTimesStream(context.Background(), rs.client(t), req, time.Millisecond, 10*time.Millisecond)
+	if _, err := stream.Recv(); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("Recv = %v, want InvalidArgument", err)
+	}
+}
+
+func TestManyTimesStreamCancelledWhileDown(t *testing.T) {
+	rs := startRestartable(t, &greetserver.Server{Interval: 20 * time.Millisecond})
+	ctx, cancel := context.WithCancel(context.Background())
+	req := &greetpb.GreetRequest{Greeting: &greetpb.Greeting{FirstName: "Nanda", LastName: "R"}}
+	stream := newManyTimesStream(ctx, rs.client(t), req, 10*time.Millisecond, 100*time.Millisecond)
+	if _, err := stream.Recv(); err != nil {
+		t.Fatal(err)
+	}
+	rs.kill()
+	time.AfterFunc(100*time.Millisecond, cancel)
+	if _, err := stream.Recv(); status.Code(err) != codes.Canceled {
+		t.Fatalf("Recv = %v, want Canceled once the caller gives up", err)
+	}
+}
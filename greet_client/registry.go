@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/resolver"
+	"gopkg.in/yaml.v3"
+)
+
+// registryScheme resolves targets such as "greet:///greet_server" from a
+// local registry file instead of DNS or Consul.
+const registryScheme = "greet"
+
+// registry is the layout of the registry file. JSON is valid YAML, so
+// either format can be used:
+//
+//	services:
+//	  greet_server:
+//	    - localhost:50051
+//	    - localhost:50052
+type registry struct {
+	Services map[string][]string `yaml:"services"`
+}
+
+func readRegistry(path string) (*registry, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	reg := &registry{}
+	if err := yaml.Unmarshal(data, reg); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return reg, nil
+}
+
+// registryBuilder builds resolvers that read the service named in the
+// target's endpoint from path and poll the file for changes every interval,
+// or never if interval is not positive.
+type registryBuilder struct {
+	path     string
+	interval time.Duration
+}
+
+func newRegistryBuilder(path string, interval time.Duration) resolver.Builder {
+	return &registryBuilder{path: path, interval: interval}
+}
+
+func (b *registryBuilder) Scheme() string { return registryScheme }
+
+func (b *registryBuilder) Build(target resolver.Target, cc resolver.ClientConn, _ resolver.BuildOptions) (resolver.Resolver, error) {
+	service := strings.TrimPrefix(target.URL.Path, "/")
+	if service == "" {
+		return nil, fmt.Errorf("registry target %q has no service name", target.URL.String())
+	}
+	r := &registryResolver{
+		path:    b.path,
+		service: service,
+		cc:      cc,
+		now:     make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+	r.update()
+	r.wg.Add(1)
+	go r.watch(b.interval)
+	return r, nil
+}
+
+type registryResolver struct {
+	path    string
+	service string
+	cc      resolver.ClientConn
+
+	stamp string
+	now   chan struct{}
+	done  chan struct{}
+	wg    sync.WaitGroup
+}
+
+// update reads the registry and pushes the service's addresses to the
+// ClientConn. A missing service or unreadable file is reported as an error,
+// which keeps the previous addresses in use.
+func (r *registryResolver) update() {
+	fi, err := os.Stat(r.path)
+	if err != nil {
+		r.cc.ReportError(err)
+		return
+	}
+	r.stamp = fmt.Sprintf("%d:%d", fi.ModTime().UnixNano(), fi.Size())
+	reg, err := readRegistry(r.path)
+	if err != nil {
+		log.Printf("Registry err : %v", err)
+		r.cc.ReportError(err)
+		return
+	}
+	addrs, ok := reg.Services[r.service]
+	if !ok || len(addrs) == 0 {
+		err := fmt.Errorf("service %q not found in %s", r.service, r.path)
+		log.Printf("Registry err : %v", err)
+		r.cc.ReportError(err)
+		return
+	}
+	state := resolver.State{}
+	for _, addr := range addrs {
+		state.Addresses = append(state.Addresses, resolver.Address{Addr: addr})
+	}
+	log.Printf("Registry %s : %v", r.service, addrs)
+	if err := r.cc.UpdateState(state); err != nil {
+		log.Printf("Registry update err : %v", err)
+	}
+}
+
+// watch re-reads the registry when its modification time or size changes,
+// or when gRPC asks for a new resolution. An interval of zero or less turns
+// polling off; the file is then only re-read on request.
+func (r *registryResolver) watch(interval time.Duration) {
+	defer r.wg.Done()
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		select {
+		case <-r.done:
+			return
+		case <-r.now:
+			r.update()
+		case <-tick:
+			fi, err := os.Stat(r.path)
+			if err == nil && fmt.Sprintf("%d:%d", fi.ModTime().UnixNano(), fi.Size()) == r.stamp {
+				continue
+			}
+			r.update()
+		}
+	}
+}
+
+func (r *registryResolver) ResolveNow(resolver.ResolveNowOptions) {
+	select {
+	case r.now <- struct{}{}:
+	default:
+	}
+}
+
+func (r *registryResolver) Close() {
+	close(r.done)
+	r.wg.Wait()
+}
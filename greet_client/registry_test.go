@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/resolver"
+)
+
+// fakeClientConn records the addresses a resolver reports.
+type fakeClientConn struct {
+	resolver.ClientConn
+	states chan []string
+}
+
+func (cc *fakeClientConn) UpdateState(s resolver.State) error {
+	var addrs []string
+	for _, a := range s.Addresses {
+		addrs = append(addrs, a.Addr)
+	}
+	cc.states <- addrs
+	return nil
+}
+
+func (cc *fakeClientConn) ReportError(err error) {}
+
+func writeRegistry(t *testing.T, path string, addrs ...string) {
+	t.Helper()
+	data := "services:\n  greet_server:\n"
+	for _, a := range addrs {
+		data += fmt.Sprintf("    - %s\n", a)
+	}
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// Make sure the size or modification time differs from the last write.
+	now := time.Now().Add(time.Duration(len(data)) * time.Second)
+	os.Chtimes(path, now, now)
+}
+
+func buildRegistry(t *testing.T, path string, interval time.Duration) (resolver.Resolver, *fakeClientConn) {
+	t.Helper()
+	u, err := url.Parse("greet:///greet_server")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cc := &fakeClientConn{states: make(chan []string, 10)}
+	r, err := newRegistryBuilder(path, interval).Build(resolver.Target{URL: *u}, cc, resolver.BuildOptions{})
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	t.Cleanup(r.Close)
+	return r, cc
+}
+
+func wantState(t *testing.T, cc *fakeClientConn, want string, wait time.Duration) {
+	t.Helper()
+	select {
+	case got := <-cc.states:
+		if fmt.Sprint(got) != want {
+			t.Fatalf("addresses = %v, want %s", got, want)
+		}
+	case <-time.After(wait):
+		t.Fatalf("no update within %v, want %s", wait, want)
+	}
+}
+
+func TestRegistryPolling(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "registry.yaml")
+	writeRegistry(t, path, "localhost:50051")
+	_, cc := buildRegistry(t, path, 10*time.Millisecond)
+	wantState(t, cc, "[localhost:50051]", time.Second)
+
+	writeRegistry(t, path, "localhost:50051", "localhost:50052")
+	wantState(t, cc, "[localhost:50051 localhost:50052]", time.Second)
+}
+
+func TestRegistryWithoutPolling(t *testing.T) {
+	for _, interval := range []time.Duration{0, -time.Second} {
+		t.Run(interval.String(), func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "registry.yaml")
+			writeRegistry(t, path, "localhost:50051")
+			r, cc := buildRegistry(t, path, interval)
+			wantState(t, cc, "[localhost:50051]", time.Second)
+
+			writeRegistry(t, path, "localhost:50052")
+			select {
+			case got := <-cc.states:
+				t.Fatalf("registry polled with interval %v: %v", interval, got)
+			case <-time.After(100 * time.Millisecond):
+			}
+
+			r.ResolveNow(resolver.ResolveNowOptions{})
+			wantState(t, cc, "[localhost:50052]", time.Second)
+		})
+	}
+}
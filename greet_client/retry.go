@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const greetMethod = "/greet.GreetService/Greet"
+
+// callPolicy controls the service config built by serviceConfig.
+type callPolicy struct {
+	// Balancer is the load balancing policy, e.g. "round_robin"; empty
+	// keeps gRPC's default of pick_first.
+	Balancer string
+	// GreetTimeout bounds every unary call, Greet across all its retries.
+	GreetTimeout time.Duration
+	// StreamTimeout bounds GreetManyTimes and LongGreet; the long-lived
+	// GreetEveryOne and SubscribeGreetings have no deadline.
+	StreamTimeout time.Duration
+	// RetryAttempts is the total number of Greet attempts, including the
+	// first; 1 disables retries.
+	RetryAttempts  int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+type methodName struct {
+	Service string `json:"service"`
+	Method  string `json:"method,omitempty"`
+}
+
+type retryPolicy struct {
+	MaxAttempts          int      `json:"maxAttempts"`
+	InitialBackoff       string   `json:"initialBackoff"`
+	MaxBackoff           string   `json:"maxBackoff"`
+	BackoffMultiplier    float64  `json:"backoffMultiplier"`
+	RetryableStatusCodes []string `json:"retryableStatusCodes"`
+}
+
+type methodConfig struct {
+	Name        []methodName `json:"name"`
+	Timeout     string       `json:"timeout,omitempty"`
+	RetryPolicy *retryPolicy `json:"retryPolicy,omitempty"`
+}
+
+// serviceConfig returns the JSON service config passed to
+// grpc.WithDefaultServiceConfig: Greet is retried on UNAVAILABLE with
+// exponential backoff, unary calls and the finite streams get a deadline,
+// and RPCs are spread over the resolved backends by the chosen balancer.
+func serviceConfig(p callPolicy) string {
+	greet := methodConfig{
+		Name:    []methodName{{Service: "greet.GreetService", Method: "Greet"}},
+		Timeout: jsonDuration(p.GreetTimeout),
+	}
+	if p.RetryAttempts > 1 {
+		greet.RetryPolicy = &retryPolicy{
+			MaxAttempts:          p.RetryAttempts,
+			InitialBackoff:       jsonDuration(p.InitialBackoff),
+			MaxBackoff:           jsonDuration(p.MaxBackoff),
+			BackoffMultiplier:    2,
+			RetryableStatusCodes: []string{"UNAVAILABLE"},
+		}
+	}
+	unary := methodConfig{
+		Name: []methodName{
+			{Service: "greet.GreetService", Method: "ListGreetings"},
+			{Service: "greet.GreetService", Method: "ResetGreetingCount"},
+			{Service: "greet.GreetService", Method: "GreetBatch"},
+		},
+		Timeout: jsonDuration(p.GreetTimeout),
+	}
+	// GreetEveryOne and SubscribeGreetings are left out: they stay open for
+	// as long as the caller wants, and a deadline would cut them off.
+	streams := methodConfig{
+		Name: []methodName{
+			{Service: "greet.GreetService", Method: "GreetManyTimes"},
+			{Service: "greet.GreetService", Method: "LongGreet"},
+		},
+		Timeout: jsonDuration(p.StreamTimeout),
+	}
+	sc := map[string]interface{}{
+		"methodConfig": []methodConfig{greet, unary, streams},
+	}
+	if p.Balancer != "" {
+		sc["loadBalancingConfig"] = []map[string]interface{}{{p.Balancer: map[string]interface{}{}}}
+	}
+	b, _ := json.Marshal(sc)
+	return string(b)
+}
+
+// jsonDuration formats d the way service configs expect, e.g. "1.5s".
+// Zero means no limit and is left out.
+func jsonDuration(d time.Duration) string {
+	if d <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("%gs", d.Seconds())
+}
+
+// hedgingInterceptor hedges Greet: if no answer has arrived after delay,
+// another copy of the request is sent, up to maxAttempts in flight. The
+// first success wins and cancels the rest. An UNAVAILABLE attempt triggers
+// the next hedge at once; any other error is returned straight away.
+//
+// grpc-go accepts hedgingPolicy in service configs but does not act on it,
+// hence the interceptor. Hedging replaces retries for Greet, so it should
+// be combined with a callPolicy whose RetryAttempts is 1.
+func hedgingInterceptor(delay time.Duration, maxAttempts int) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		out, ok := reply.(proto.Message)
+		if method != greetMethod || maxAttempts <= 1 || !ok {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		type result struct {
+			reply proto.Message
+			err   error
+		}
+		results := make(chan result, maxAttempts)
+		sent := 0
+		hedge := func() {
+			sent++
+			go func() {
+				// Each attempt decodes into its own message.
+				r := proto.Clone(out)
+				r.Reset()
+				results <- result{r, invoker(ctx, method, req, r, cc, opts...)}
+			}()
+		}
+
+		hedge()
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		var lastErr error
+		for done := 0; ; {
+			select {
+			case <-timer.C:
+				if sent < maxAttempts {
+					hedge()
+					timer.Reset(delay)
+				}
+			case res := <-results:
+				done++
+				if res.err == nil {
+					out.Reset()
+					proto.Merge(out, res.reply)
+					return nil
+				}
+				if status.Code(res.err) != codes.Unavailable {
+					return res.err
+				}
+				lastErr = res.err
+				if sent < maxAttempts {
+					hedge()
+					timer.Reset(delay)
+				} else if done == sent {
+					return lastErr
+				}
+			case <-ctx.Done():
+				return status.FromContextError(ctx.Err()).Err()
+			}
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"../greetpb"
+	"../greetpb/greetmock"
+	"../greetserver"
+	"../greettest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// testPolicy is callPolicy with timings short enough for tests.
+var testPolicy = callPolicy{
+	GreetTimeout:   5 * time.Second,
+	StreamTimeout:  100 * time.Millisecond,
+	RetryAttempts:  4,
+	InitialBackoff: 10 * time.Millisecond,
+	MaxBackoff:     50 * time.Millisecond,
+}
+
+// dialPolicy serves impl in-process and dials it with the service config
+// built from p.
+func dialPolicy(t *testing.T, impl greetpb.GreetServiceServer, p callPolicy) greetpb.GreetServiceClient {
+	t.Helper()
+	srv := greettest.NewServer(impl)
+	t.Cleanup(srv.Close)
+	client, conn, err := srv.Client(context.Background(), grpc.WithDefaultServiceConfig(serviceConfig(p)))
+	if err != nil {
+		t.Fatalf("Client: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return client
+}
+
+// flaky returns a counter and a func that fails with UNAVAILABLE for the
+// first n calls counted on it.
+func flaky(n int64) (*int64, func() error) {
+	calls := new(int64)
+	return calls, func() error {
+		if atomic.AddInt64(calls, 1) <= n {
+			return status.Error(codes.Unavailable, "server restarting")
+		}
+		return nil
+	}
+}
+
+func TestServiceConfigTimeouts(t *testing.T) {
+	var sc struct {
+		MethodConfig []methodConfig `json:"methodConfig"`
+	}
+	if err := json.Unmarshal([]byte(serviceConfig(testPolicy)), &sc); err != nil {
+		t.Fatal(err)
+	}
+	timeouts := map[string]string{}
+	for _, mc := range sc.MethodConfig {
+		for _, n := range mc.Name {
+			if n.Method == "" {
+				t.Errorf("service-wide method config %+v also applies to the long-lived streams", mc)
+			}
+			timeouts[n.Method] = mc.Timeout
+		}
+	}
+	for method, want := range map[string]string{
+		"Greet":              "5s",
+		"ListGreetings":      "5s",
+		"ResetGreetingCount": "5s",
+		"GreetBatch":         "5s",
+		"GreetManyTimes":     "0.1s",
+		"LongGreet":          "0.1s",
+	} {
+		if got := timeouts[method]; got != want {
+			t.Errorf("%s timeout = %q, want %q", method, got, want)
+		}
+	}
+	for _, method := range []string{"GreetEveryOne", "SubscribeGreetings"} {
+		if _, ok := timeouts[method]; ok {
+			t.Errorf("%s has a timeout of %q, want none", method, timeouts[method])
+		}
+	}
+}
+
+func TestGreetRetriesFlakyServer(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		failures int64
+		attempts int
+		want     codes.Code
+	}{
+		{"recovers", 3, 4, codes.OK},
+		{"gives up", 3, 3, codes.Unavailable},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			calls, fail := flaky(tc.failures)
+			p := testPolicy
+			p.RetryAttempts = tc.attempts
+			client := dialPolicy(t, &greetmock.Server{
+				GreetFunc: func(ctx context.Context, req *greetpb.GreetRequest) (*greetpb.GreetResponse, error) {
+					if err := fail(); err != nil {
+						return nil, err
+					}
+					return &greetpb.GreetResponse{Result: "Hi " + req.GetGreeting().GetFirstName()}, nil
+				},
+			}, p)
+			_, err := client.Greet(context.Background(), &greetpb.GreetRequest{Greeting: &greetpb.Greeting{FirstName: "Nanda"}})
+			if status.Code(err) != tc.want {
+				t.Fatalf("Greet = %v, want %v", err, tc.want)
+			}
+			if got := atomic.LoadInt64(calls); got != int64(tc.attempts) {
+				t.Errorf("server saw %d calls, want %d", got, tc.attempts)
+			}
+		})
+	}
+}
+
+func TestStreamTimeoutBoundsGreetManyTimes(t *testing.T) {
+	client := dialPolicy(t, &greetserver.Server{Interval: time.Hour}, testPolicy)
+	stream, err := client.GreetManyTimes(context.Background(), &greetpb.GreetRequest{Greeting: &greetpb.Greeting{FirstName: "Nanda"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for err == nil {
+		_, err = stream.Recv()
+	}
+	if status.Code(err) != codes.DeadlineExceeded {
+		t.Fatalf("Recv = %v, want DeadlineExceeded after the stream timeout", err)
+	}
+}
+
+// The reconnecting GreetEveryOne stream must ride out a flaky server and
+// keep going for longer than the stream timeout.
+func TestEveryOneOutlivesStreamTimeout(t *testing.T) {
+	calls, fail := flaky(2)
+	impl := &greetserver.Server{}
+	client := dialPolicy(t, &greetmock.Server{
+		GreetEveryOneFunc: func(stream greetpb.GreetService_GreetEveryOneServer) error {
+			if err := fail(); err != nil {
+				return err
+			}
+			return impl.GreetEveryOne(stream)
+		},
+	}, testPolicy)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream := newEveryOneStream(ctx, client, 10*time.Millisecond, 50*time.Millisecond)
+	names := []string{"nanda", "kumar", "stephane", "maarek"}
+	for _, name := range names {
+		if err := stream.Send(&greetpb.GreetRequest{Greeting: &greetpb.Greeting{FirstName: name}}); err != nil {
+			t.Fatalf("Send: %v", err)
+		}
+		res, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("Recv: %v", err)
+		}
+		if want := "Hello " + name + "! "; res.GetResult() != want {
+			t.Errorf("Result = %q, want %q", res.GetResult(), want)
+		}
+		time.Sleep(testPolicy.StreamTimeout)
+	}
+	if err := stream.CloseSend(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stream.Recv(); err != io.EOF {
+		t.Fatalf("Recv after CloseSend = %v, want io.EOF", err)
+	}
+	if got := atomic.LoadInt64(calls); got != 3 {
+		t.Errorf("server saw %d GreetEveryOne calls, want 3 (two failures, one session)", got)
+	}
+}
+
+func TestSubscribeOutlivesStreamTimeout(t *testing.T) {
+	client := dialPolicy(t, &greetserver.Server{}, testPolicy)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sub, err := client.SubscribeGreetings(ctx, &greetpb.SubscribeGreetingsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(3 * testPolicy.StreamTimeout)
+	if _, err := client.Greet(ctx, &greetpb.GreetRequest{Greeting: &greetpb.Greeting{FirstName: "Nanda"}}); err != nil {
+		t.Fatalf("Greet: %v", err)
+	}
+	ev, err := sub.Recv()
+	if err != nil {
+		t.Fatalf("Recv after %v: %v", 3*testPolicy.StreamTimeout, err)
+	}
+	if ev.GetRpc() != greetpb.RpcType_RPC_TYPE_GREET {
+		t.Errorf("event for %v, want GREET", ev.GetRpc())
+	}
+}
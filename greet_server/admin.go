@@ -0,0 +1,259 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"log"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"../greetlog"
+	"../greetpb"
+	"github.com/golang/protobuf/ptypes"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/stats"
+	"google.golang.org/grpc/status"
+)
+
+// version is the greet_server release, set at build time with
+// -ldflags "-X main.version=v1.2.3". Without it the VCS revision recorded
+// by the go command is reported.
+var version string
+
+func buildVersion() string {
+	if version != "" {
+		return version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range info.Settings {
+			if s.Key == "vcs.revision" {
+				return s.Value
+			}
+		}
+	}
+	return "dev"
+}
+
+// adminServer implements greetpb.GreetAdminServer.
+type adminServer struct {
+	cfg     *Config
+	started time.Time
+	conns   *connTracker
+}
+
+func (a *adminServer) GetServerStatus(ctx context.Context, req *greetpb.GetServerStatusRequest) (*greetpb.ServerStatus, error) {
+	started, err := ptypes.TimestampProto(a.started)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "start time : %v", err)
+	}
+	return &greetpb.ServerStatus{
+		Version:       buildVersion(),
+		GoVersion:     runtime.Version(),
+		StartTime:     started,
+		Uptime:        ptypes.DurationProto(time.Since(a.started)),
+		ActiveStreams: a.conns.activeStreams(),
+		LogLevel:      greetpb.LogLevel(greetlog.CurrentLevel()),
+	}, nil
+}
+
+func (a *adminServer) ListPeers(ctx context.Context, req *greetpb.ListPeersRequest) (*greetpb.ListPeersResponse, error) {
+	return &greetpb.ListPeersResponse{Peers: a.conns.peers()}, nil
+}
+
+func (a *adminServer) GetConfig(ctx context.Context, req *greetpb.GetConfigRequest) (*greetpb.GetConfigResponse, error) {
+	cfg := a.cfg.redacted()
+	cfg.LogLevel = greetlog.CurrentLevel().String()
+	return &greetpb.GetConfigResponse{Yaml: cfg.String()}, nil
+}
+
+func (a *adminServer) SetLogLevel(ctx context.Context, req *greetpb.SetLogLevelRequest) (*greetpb.SetLogLevelResponse, error) {
+	if _, ok := greetpb.LogLevel_name[int32(req.GetLevel())]; !ok || req.GetLevel() == greetpb.LogLevel_LOG_LEVEL_UNSPECIFIED {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown log level %v", req.GetLevel())
+	}
+	level := greetlog.Level(req.GetLevel())
+	previous := greetlog.SetLevel(level)
+	// Always written, whatever the new level, so the change can be traced.
+	log.Printf("Log level changed from %v to %v by %s", previous, level, adminCaller(ctx))
+	return &greetpb.SetLogLevelResponse{PreviousLevel: greetpb.LogLevel(previous)}, nil
+}
+
+func adminCaller(ctx context.Context) string {
+	if p, ok := peer.FromContext(ctx); ok {
+		return p.Addr.String()
+	}
+	return "unknown"
+}
+
+// adminUnaryInterceptor and adminStreamInterceptor reject admin calls that
+// do not carry token; other calls are not affected. With an empty token,
+// admin access is disabled and every admin call is refused.
+func adminUnaryInterceptor(token string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := checkAdmin(ctx, info.FullMethod, token); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func adminStreamInterceptor(token string) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := checkAdmin(ss.Context(), info.FullMethod, token); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+// adminMethods are the GreetService calls that, like all of GreetAdmin,
+// are reserved for admins.
+var adminMethods = map[string]bool{
+	"/greet.GreetService/ResetGreetingCount": true,
+}
+
+func checkAdmin(ctx context.Context, method, token string) error {
+	if !strings.HasPrefix(method, "/greet.GreetAdmin/") && !adminMethods[method] {
+		return nil
+	}
+	if token == "" {
+		return status.Error(codes.PermissionDenied, "admin access is disabled")
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	auth := md.Get("authorization")
+	if len(auth) == 0 || !strings.HasPrefix(auth[0], "Bearer ") {
+		return status.Error(codes.Unauthenticated, "admin token required")
+	}
+	if subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(auth[0], "Bearer ")), []byte(token)) != 1 {
+		return status.Error(codes.PermissionDenied, "invalid admin token")
+	}
+	return nil
+}
+
+// connTracker is a stats.Handler that keeps the open client connections
+// and the calls in progress on each of them.
+type connTracker struct {
+	mu      sync.Mutex
+	conns   map[*trackedConn]struct{}
+	streams map[string]int64
+}
+
+type trackedConn struct {
+	addr     string
+	identity string
+	since    time.Time
+	streams  int64
+}
+
+type trackedRPC struct {
+	conn   *trackedConn
+	method string
+}
+
+type connKey struct{}
+
+type rpcKey struct{}
+
+func newConnTracker() *connTracker {
+	return &connTracker{
+		conns:   make(map[*trackedConn]struct{}),
+		streams: make(map[string]int64),
+	}
+}
+
+func (t *connTracker) TagConn(ctx context.Context, info *stats.ConnTagInfo) context.Context {
+	return context.WithValue(ctx, connKey{}, &trackedConn{addr: info.RemoteAddr.String(), since: time.Now()})
+}
+
+func (t *connTracker) HandleConn(ctx context.Context, s stats.ConnStats) {
+	c, ok := ctx.Value(connKey{}).(*trackedConn)
+	if !ok {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	switch s.(type) {
+	case *stats.ConnBegin:
+		t.conns[c] = struct{}{}
+	case *stats.ConnEnd:
+		delete(t.conns, c)
+	}
+}
+
+func (t *connTracker) TagRPC(ctx context.Context, info *stats.RPCTagInfo) context.Context {
+	c, _ := ctx.Value(connKey{}).(*trackedConn)
+	return context.WithValue(ctx, rpcKey{}, &trackedRPC{conn: c, method: info.FullMethodName})
+}
+
+func (t *connTracker) HandleRPC(ctx context.Context, s stats.RPCStats) {
+	r, ok := ctx.Value(rpcKey{}).(*trackedRPC)
+	if !ok {
+		return
+	}
+	var delta int64
+	switch s.(type) {
+	case *stats.Begin:
+		delta = 1
+	case *stats.End:
+		delta = -1
+	default:
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.streams[r.method] += delta; t.streams[r.method] <= 0 {
+		delete(t.streams, r.method)
+	}
+	if r.conn == nil {
+		return
+	}
+	r.conn.streams += delta
+	if r.conn.identity == "" {
+		if p, ok := peer.FromContext(ctx); ok {
+			if info, ok := p.AuthInfo.(credentials.TLSInfo); ok && len(info.State.VerifiedChains) > 0 {
+				r.conn.identity = info.State.VerifiedChains[0][0].Subject.CommonName
+			}
+		}
+	}
+}
+
+// activeStreams returns the calls in progress by full method name.
+func (t *connTracker) activeStreams() map[string]int64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	streams := make(map[string]int64, len(t.streams))
+	for method, n := range t.streams {
+		streams[method] = n
+	}
+	return streams
+}
+
+// peers returns the open connections, oldest first.
+func (t *connTracker) peers() []*greetpb.PeerInfo {
+	t.mu.Lock()
+	conns := make([]trackedConn, 0, len(t.conns))
+	for c := range t.conns {
+		conns = append(conns, *c)
+	}
+	t.mu.Unlock()
+	sort.Slice(conns, func(i, j int) bool { return conns[i].since.Before(conns[j].since) })
+
+	peers := make([]*greetpb.PeerInfo, 0, len(conns))
+	for _, c := range conns {
+		since, _ := ptypes.TimestampProto(c.since)
+		peers = append(peers, &greetpb.PeerInfo{
+			Address:       c.addr,
+			Identity:      c.identity,
+			ConnectedTime: since,
+			ActiveStreams: c.streams,
+		})
+	}
+	return peers
+}
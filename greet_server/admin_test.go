@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"../greetpb"
+	"../greetserver"
+	"../greetstore"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// adminClient serves GreetService behind the admin interceptors, as main
+// does, and returns a gRPC client and the URL of its Connect handler.
+func adminClient(t *testing.T, token string) (greetpb.GreetServiceClient, string) {
+	t.Helper()
+	impl := &greetserver.Server{Counter: greetstore.NewMemoryCounter()}
+	unary := []grpc.UnaryServerInterceptor{adminUnaryInterceptor(token)}
+	stream := []grpc.StreamServerInterceptor{adminStreamInterceptor(token)}
+	s := grpc.NewServer(grpc.ChainUnaryInterceptor(unary...), grpc.ChainStreamInterceptor(stream...))
+	greetpb.RegisterGreetServiceServer(s, impl)
+	t.Cleanup(s.Stop)
+	conn, err := dialInProcess(context.Background(), s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	ts := httptest.NewServer(newConnectHandler(impl, connectOptions{Unary: unary, Stream: stream}))
+	t.Cleanup(ts.Close)
+	return greetpb.NewGreetServiceClient(conn), ts.URL
+}
+
+func TestResetGreetingCountNeedsAdminToken(t *testing.T) {
+	for _, tc := range []struct {
+		name, token, auth string
+		want              codes.Code
+	}{
+		{"no token", "s3cret", "", codes.Unauthenticated},
+		{"not a bearer token", "s3cret", "s3cret", codes.Unauthenticated},
+		{"wrong token", "s3cret", "Bearer guess", codes.PermissionDenied},
+		{"right token", "s3cret", "Bearer s3cret", codes.OK},
+		{"admin disabled", "", "Bearer ", codes.PermissionDenied},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			client, url := adminClient(t, tc.token)
+			ctx := context.Background()
+			if tc.auth != "" {
+				ctx = metadata.AppendToOutgoingContext(ctx, "authorization", tc.auth)
+			}
+			req := &greetpb.ResetGreetingCountRequest{Greeting: &greetpb.Greeting{FirstName: "Nanda", LastName: "R"}}
+			if _, err := client.ResetGreetingCount(ctx, req); status.Code(err) != tc.want {
+				t.Errorf("gRPC: %v, want %v", err, tc.want)
+			}
+
+			httpReq, err := http.NewRequest(http.MethodPost, url+"/greet.GreetService/ResetGreetingCount",
+				strings.NewReader(`{"greeting":{"first_name":"Nanda","last_name":"R"}}`))
+			if err != nil {
+				t.Fatal(err)
+			}
+			httpReq.Header.Set("Content-Type", "application/json")
+			if tc.auth != "" {
+				httpReq.Header.Set("Authorization", tc.auth)
+			}
+			res, err := http.DefaultClient.Do(httpReq)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res.Body.Close()
+			if ok := res.StatusCode == http.StatusOK; ok != (tc.want == codes.OK) {
+				t.Errorf("Connect: HTTP %d, want %v", res.StatusCode, tc.want)
+			}
+		})
+	}
+}
+
+// Other GreetService calls need no token, even with admin disabled.
+func TestGreetNeedsNoAdminToken(t *testing.T) {
+	for _, token := range []string{"s3cret", ""} {
+		client, _ := adminClient(t, token)
+		req := &greetpb.GreetRequest{Greeting: &greetpb.Greeting{FirstName: "Nanda"}}
+		if _, err := client.Greet(context.Background(), req); err != nil {
+			t.Errorf("token %q: Greet: %v", token, err)
+		}
+	}
+}
+
+func TestGetConfigRedactsToken(t *testing.T) {
+	t.Setenv("GREET_ADMIN_TOKEN", "s3cret")
+	cfg, _, err := loadConfig([]string{"-admin"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := (&adminServer{cfg: cfg}).GetConfig(context.Background(), &greetpb.GetConfigRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(res.GetYaml(), "s3cret") {
+		t.Errorf("GetConfig shows the token:\n%s", res.GetYaml())
+	}
+}
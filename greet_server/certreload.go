@@ -0,0 +1,134 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"../greetlog"
+)
+
+// certReloader hands out the most recently loaded server key pair and
+// client CA pool. New files are picked up by watch and swapped in atomically,
+// so only handshakes after a rotation see the new certificate; established
+// connections, and the streams on them, are left alone.
+type certReloader struct {
+	certFile, keyFile, caFile string
+
+	cert  atomic.Value // *tls.Certificate
+	pool  atomic.Value // *x509.CertPool
+	stamp string
+}
+
+func newCertReloader(certFile, keyFile, caFile string) (*certReloader, error) {
+	r := &certReloader{certFile: certFile, keyFile: keyFile, caFile: caFile}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// reload loads the files and, only if all of them are valid, replaces the
+// current certificate and pool.
+func (r *certReloader) reload() error {
+	stamp, err := r.fileStamp()
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return err
+	}
+	cert.Leaf = leaf
+	var pool *x509.CertPool
+	if r.caFile != "" {
+		pem, err := ioutil.ReadFile(r.caFile)
+		if err != nil {
+			return err
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates found in %s", r.caFile)
+		}
+		r.pool.Store(pool)
+	}
+	r.cert.Store(&cert)
+	r.stamp = stamp
+	greetlog.Infof("Loaded TLS certificate %s for %v, expires %v", r.certFile, leaf.Subject, leaf.NotAfter)
+	return nil
+}
+
+// fileStamp summarises the modification time and size of every watched file.
+func (r *certReloader) fileStamp() (string, error) {
+	stamp := ""
+	for _, name := range []string{r.certFile, r.keyFile, r.caFile} {
+		if name == "" {
+			continue
+		}
+		fi, err := os.Stat(name)
+		if err != nil {
+			return "", err
+		}
+		stamp += fmt.Sprintf("%s:%d:%d;", name, fi.ModTime().UnixNano(), fi.Size())
+	}
+	return stamp, nil
+}
+
+// watch polls the files every interval and reloads them when they change,
+// until stop is closed. A failed reload is logged and the previous
+// certificate stays in use.
+func (r *certReloader) watch(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		stamp, err := r.fileStamp()
+		if err != nil {
+			greetlog.Errorf("TLS reload err : %v", err)
+			continue
+		}
+		if stamp == r.stamp {
+			continue
+		}
+		if err := r.reload(); err != nil {
+			greetlog.Errorf("TLS reload err : %v", err)
+		}
+	}
+}
+
+func (r *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return r.cert.Load().(*tls.Certificate), nil
+}
+
+// TLSConfig returns a server config that always uses the current
+// certificate and, when a client CA file is set, requires client
+// certificates signed by the current CA pool.
+func (r *certReloader) TLSConfig(nextProtos ...string) *tls.Config {
+	base := &tls.Config{
+		GetCertificate: r.GetCertificate,
+		NextProtos:     nextProtos,
+	}
+	if r.caFile == "" {
+		return base
+	}
+	base.ClientAuth = tls.RequireAndVerifyClientCert
+	base.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		cfg := base.Clone()
+		cfg.GetConfigForClient = nil
+		cfg.ClientCAs = r.pool.Load().(*x509.CertPool)
+		return cfg, nil
+	}
+	return base
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"../greetpb"
+	"../greetserver"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// served returns the certificate a new TLS handshake with addr gets.
+func served(t *testing.T, addr string) *x509.Certificate {
+	t.Helper()
+	conn, err := tls.Dial("tcp", addr, &tls.Config{InsecureSkipVerify: true, NextProtos: []string{"h2"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	return conn.ConnectionState().PeerCertificates[0]
+}
+
+func waitServed(t *testing.T, addr string, want *x509.Certificate) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !served(t, addr).Equal(want) {
+		if time.Now().After(deadline) {
+			t.Fatal("the rotated certificate was never served")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func greetOn(t *testing.T, stream greetpb.GreetService_GreetEveryOneClient, name string) {
+	t.Helper()
+	if err := stream.Send(&greetpb.GreetRequest{Greeting: &greetpb.Greeting{FirstName: name}}); err != nil {
+		t.Fatal(err)
+	}
+	if res, err := stream.Recv(); err != nil || res.GetResult() != "Hello "+name+"! " {
+		t.Fatalf("%s: %v, %v", name, res, err)
+	}
+}
+
+// New handshakes get rotated files while an open stream keeps going on its
+// connection, and a pair caught half-written is not loaded.
+func TestCertRotation(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.pem")
+	oldCert, oldKey, old := newTestCert(t)
+	writeFile(t, certFile, oldCert)
+	writeFile(t, keyFile, oldKey)
+	certs, err := newCertReloader(certFile, keyFile, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stop := make(chan struct{})
+	defer close(stop)
+	go certs.watch(10*time.Millisecond, stop)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := grpc.NewServer(grpc.Creds(credentials.NewTLS(certs.TLSConfig())))
+	greetpb.RegisterGreetServiceServer(s, &greetserver.Server{})
+	go s.Serve(lis)
+	defer s.Stop()
+	addr := lis.Addr().String()
+
+	// The client only trusts the first certificate, so its stream can only
+	// carry on over the connection it was opened on.
+	pool := x509.NewCertPool()
+	pool.AddCert(old)
+	conn, err := grpc.Dial(addr, grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{RootCAs: pool, ServerName: "localhost"})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	stream, err := greetpb.NewGreetServiceClient(conn).GreetEveryOne(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	greetOn(t, stream, "Nanda")
+
+	newCert, newKey, rotated := newTestCert(t)
+	writeFile(t, certFile, newCert)
+	writeFile(t, keyFile, newKey)
+	waitServed(t, addr, rotated)
+	greetOn(t, stream, "Kumar")
+
+	// The next certificate without its key does not match the key on disk,
+	// so the previous pair stays until the key is written too.
+	nextCert, nextKey, next := newTestCert(t)
+	writeFile(t, certFile, nextCert)
+	time.Sleep(100 * time.Millisecond)
+	if !served(t, addr).Equal(rotated) {
+		t.Fatal("a half-written pair replaced the certificate")
+	}
+	writeFile(t, keyFile, nextKey)
+	waitServed(t, addr, next)
+	greetOn(t, stream, "R")
+}
+
+// A rotated client CA file decides which client certificates new
+// handshakes accept.
+func TestClientCARotation(t *testing.T) {
+	serverCert, serverKey, serverPool := writeTestCert(t)
+	caFile := filepath.Join(t.TempDir(), "ca.crt")
+	oldCert, oldKey, _ := newTestCert(t)
+	writeFile(t, caFile, oldCert)
+	certs, err := newCertReloader(serverCert, serverKey, caFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stop := make(chan struct{})
+	defer close(stop)
+	go certs.watch(10*time.Millisecond, stop)
+
+	lis, err := tls.Listen("tcp", "127.0.0.1:0", certs.TLSConfig("http/1.1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lis.Close()
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			// Reading makes the handshake happen; the client closes first.
+			go func() {
+				conn.Read(make([]byte, 1))
+				conn.Close()
+			}()
+		}
+	}()
+	handshake := func(certPEM, keyPEM []byte) error {
+		pair, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			t.Fatal(err)
+		}
+		conn, err := tls.Dial("tcp", lis.Addr().String(), &tls.Config{
+			RootCAs:      serverPool,
+			ServerName:   "localhost",
+			Certificates: []tls.Certificate{pair},
+		})
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		// The server's verdict on the client certificate arrives after the
+		// client's side of the handshake, so read to receive it.
+		conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+		if _, err := conn.Read(make([]byte, 1)); err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Timeout() {
+				return nil
+			}
+			return err
+		}
+		return nil
+	}
+	if err := handshake(oldCert, oldKey); err != nil {
+		t.Fatalf("client certificate of the CA: %v", err)
+	}
+
+	newCert, newKey, _ := newTestCert(t)
+	writeFile(t, caFile, newCert)
+	deadline := time.Now().Add(5 * time.Second)
+	for handshake(newCert, newKey) != nil {
+		if time.Now().After(deadline) {
+			t.Fatal("the rotated client CA was never used")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := handshake(oldCert, oldKey); err == nil {
+		t.Error("client certificate of the replaced CA still accepted")
+	}
+}
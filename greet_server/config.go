@@ -0,0 +1,552 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"../greetlog"
+	"../greetserver"
+	"../greetstore"
+	"gopkg.in/yaml.v3"
+)
+
+// Config holds every greet_server setting. Values are layered, each source
+// overriding the previous one: defaults, the YAML file given with -config,
+// GREET_* environment variables, then flags set on the command line.
+type Config struct {
+	Addr             string            `yaml:"addr"`
+	TLS              TLSConfig         `yaml:"tls"`
+	Keepalive        KeepaliveConfig   `yaml:"keepalive"`
+	MaxRecvMsgSize   int               `yaml:"max_recv_msg_size"`
+	MaxSendMsgSize   int               `yaml:"max_send_msg_size"`
+	StreamInterval   time.Duration     `yaml:"stream_interval"`
+	EveryOne         EveryOneConfig    `yaml:"greet_every_one"`
+	SubscriberBuffer int               `yaml:"subscriber_buffer"`
+	MaxBatchSize     int               `yaml:"max_batch_size"`
+	History          HistoryConfig     `yaml:"history"`
+	Counts           CountsConfig      `yaml:"counts"`
+	Idempotency      IdempotencyConfig `yaml:"idempotency"`
+	LogLevel         string            `yaml:"log_level"`
+	Admin            AdminConfig       `yaml:"admin"`
+	Interceptors     InterceptorConfig `yaml:"interceptors"`
+	Features         FeatureConfig     `yaml:"features"`
+}
+
+// TLSConfig selects the server key pair. When ClientCAFile is set, clients
+// must present a certificate signed by one of its CAs (mTLS). All three files
+// are re-read whenever they change, checked every ReloadInterval.
+type TLSConfig struct {
+	Enabled        bool          `yaml:"enabled"`
+	CertFile       string        `yaml:"cert_file"`
+	KeyFile        string        `yaml:"key_file"`
+	ClientCAFile   string        `yaml:"client_ca_file"`
+	ReloadInterval time.Duration `yaml:"reload_interval"`
+}
+
+// KeepaliveConfig maps onto keepalive.ServerParameters and
+// keepalive.EnforcementPolicy. A zero connection idle, age or grace means
+// unlimited. Clients pinging more often than MinTime, or without an active
+// stream when PermitWithoutStream is false, are sent GOAWAY.
+type KeepaliveConfig struct {
+	Time                  time.Duration `yaml:"time"`
+	Timeout               time.Duration `yaml:"timeout"`
+	MaxConnectionIdle     time.Duration `yaml:"max_connection_idle"`
+	MaxConnectionAge      time.Duration `yaml:"max_connection_age"`
+	MaxConnectionAgeGrace time.Duration `yaml:"max_connection_age_grace"`
+	MinTime               time.Duration `yaml:"min_time"`
+	PermitWithoutStream   bool          `yaml:"permit_without_stream"`
+}
+
+// EveryOneConfig bounds the responses queued for each GreetEveryOne client.
+// QueuePolicy is block, drop-oldest or error.
+type EveryOneConfig struct {
+	QueueSize   int    `yaml:"queue_size"`
+	QueuePolicy string `yaml:"queue_policy"`
+}
+
+// HistoryConfig selects where greetings are recorded for ListGreetings:
+// "memory" keeps the latest MaxRecords in process, "bolt" persists the latest
+// MaxRecords (all of them when zero) to Path, and "none" turns the history
+// off.
+type HistoryConfig struct {
+	Backend    string `yaml:"backend"`
+	Path       string `yaml:"path"`
+	MaxRecords int    `yaml:"max_records"`
+}
+
+// CountsConfig selects where the per-person greet counts are kept:
+// "memory" in process, "bolt" in the file at Path, or "none" to not count.
+type CountsConfig struct {
+	Backend string `yaml:"backend"`
+	Path    string `yaml:"path"`
+}
+
+// IdempotencyConfig bounds the Greet responses remembered for replays of
+// the same idempotency key: at most Size keys, each for TTL. A zero Size
+// turns idempotency keys off.
+type IdempotencyConfig struct {
+	Size int           `yaml:"size"`
+	TTL  time.Duration `yaml:"ttl"`
+}
+
+// AdminConfig registers the GreetAdmin service. Its callers, and those of
+// GreetService's ResetGreetingCount, must send Token as
+// "authorization: Bearer <token>" metadata; without Enabled, those calls are
+// refused.
+type AdminConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Token   string `yaml:"token"`
+}
+
+type InterceptorConfig struct {
+	Logging  bool        `yaml:"logging"`
+	Recovery bool        `yaml:"recovery"`
+	Cache    CacheConfig `yaml:"cache"`
+}
+
+// CacheConfig turns on the Greet response cache, holding up to Size
+// responses for TTL each.
+type CacheConfig struct {
+	Enabled bool          `yaml:"enabled"`
+	Size    int           `yaml:"size"`
+	TTL     time.Duration `yaml:"ttl"`
+}
+
+type FeatureConfig struct {
+	Reflection  bool   `yaml:"reflection"`
+	HTTPAddr    string `yaml:"http_addr"`
+	GRPCWebAddr string `yaml:"grpcweb_addr"`
+	CORSOrigins string `yaml:"cors_origins"`
+	Connect     bool   `yaml:"connect"`
+	Mux         bool   `yaml:"mux"`
+	MetricsAddr string `yaml:"metrics_addr"`
+}
+
+func defaultConfig() *Config {
+	return &Config{
+		Addr: "localhost:50051",
+		TLS: TLSConfig{
+			CertFile:       "greet/ssl/server.crt",
+			KeyFile:        "greet/ssl/server.pem",
+			ReloadInterval: 30 * time.Second,
+		},
+		Keepalive: KeepaliveConfig{
+			Time:    2 * time.Hour,
+			Timeout: 20 * time.Second,
+			MinTime: 5 * time.Minute,
+		},
+		MaxRecvMsgSize: 4 * 1024 * 1024,
+		MaxSendMsgSize: 4 * 1024 * 1024,
+		StreamInterval: 1000 * time.Millisecond,
+		EveryOne: EveryOneConfig{
+			QueueSize:   16,
+			QueuePolicy: string(greetserver.QueueBlock),
+		},
+		SubscriberBuffer: 64,
+		MaxBatchSize:     100,
+		History: HistoryConfig{
+			Backend:    "memory",
+			Path:       "greetings.db",
+			MaxRecords: 10000,
+		},
+		Counts: CountsConfig{
+			Backend: "memory",
+			Path:    "greetings.db",
+		},
+		Idempotency: IdempotencyConfig{
+			Size: 10000,
+			TTL:  10 * time.Minute,
+		},
+		LogLevel: "info",
+		Interceptors: InterceptorConfig{
+			Recovery: true,
+			Cache: CacheConfig{
+				Size: 1000,
+				TTL:  time.Minute,
+			},
+		},
+		Features: FeatureConfig{
+			Reflection:  true,
+			CORSOrigins: "*",
+		},
+	}
+}
+
+// option is a setting that can be overridden by a flag and an environment
+// variable. The variable name is GREET_ followed by the flag name in upper
+// case with dashes replaced by underscores.
+type option struct {
+	name  string
+	usage string
+	field func(c *Config) interface{}
+}
+
+var options = []option{
+	{"addr", "gRPC listen address", func(c *Config) interface{} { return &c.Addr }},
+	{"tls", "serve with TLS", func(c *Config) interface{} { return &c.TLS.Enabled }},
+	{"cert", "TLS certificate file", func(c *Config) interface{} { return &c.TLS.CertFile }},
+	{"key", "TLS private key file", func(c *Config) interface{} { return &c.TLS.KeyFile }},
+	{"client-ca", "CA file for verifying client certificates; enables mTLS when set", func(c *Config) interface{} { return &c.TLS.ClientCAFile }},
+	{"cert-reload-interval", "how often to check TLS files for changes (0 disables reloading)", func(c *Config) interface{} { return &c.TLS.ReloadInterval }},
+	{"keepalive-time", "ping clients after this long without activity", func(c *Config) interface{} { return &c.Keepalive.Time }},
+	{"keepalive-timeout", "close the connection if a ping is not answered in time", func(c *Config) interface{} { return &c.Keepalive.Timeout }},
+	{"max-connection-idle", "close connections idle for this long", func(c *Config) interface{} { return &c.Keepalive.MaxConnectionIdle }},
+	{"max-connection-age", "close connections older than this", func(c *Config) interface{} { return &c.Keepalive.MaxConnectionAge }},
+	{"max-connection-age-grace", "time allowed for RPCs to finish after max-connection-age", func(c *Config) interface{} { return &c.Keepalive.MaxConnectionAgeGrace }},
+	{"keepalive-min-time", "minimum interval clients may ping at", func(c *Config) interface{} { return &c.Keepalive.MinTime }},
+	{"keepalive-permit-without-stream", "allow client pings when no RPC is active", func(c *Config) interface{} { return &c.Keepalive.PermitWithoutStream }},
+	{"max-recv-msg-size", "largest message the server accepts, in bytes", func(c *Config) interface{} { return &c.MaxRecvMsgSize }},
+	{"max-send-msg-size", "largest message the server sends, in bytes", func(c *Config) interface{} { return &c.MaxSendMsgSize }},
+	{"stream-interval", "delay between GreetManyTimes messages", func(c *Config) interface{} { return &c.StreamInterval }},
+	{"queue-size", "responses queued per GreetEveryOne stream for a slow client", func(c *Config) interface{} { return &c.EveryOne.QueueSize }},
+	{"queue-policy", "what GreetEveryOne does when the queue is full: block, drop-oldest or error", func(c *Config) interface{} { return &c.EveryOne.QueuePolicy }},
+	{"subscriber-buffer", "events a SubscribeGreetings stream may fall behind before it is disconnected", func(c *Config) interface{} { return &c.SubscriberBuffer }},
+	{"max-batch-size", "greetings accepted by one GreetBatch call", func(c *Config) interface{} { return &c.MaxBatchSize }},
+	{"history", "where to record greetings: memory, bolt or none", func(c *Config) interface{} { return &c.History.Backend }},
+	{"history-path", "bbolt file used by the bolt history", func(c *Config) interface{} { return &c.History.Path }},
+	{"history-max-records", "greetings kept by the history; 0 keeps all of them with bolt", func(c *Config) interface{} { return &c.History.MaxRecords }},
+	{"counts", "where to keep greet counts: memory, bolt or none", func(c *Config) interface{} { return &c.Counts.Backend }},
+	{"counts-path", "bbolt file used by the bolt counts", func(c *Config) interface{} { return &c.Counts.Path }},
+	{"idempotency-size", "idempotency keys whose Greet response is remembered; 0 disables them", func(c *Config) interface{} { return &c.Idempotency.Size }},
+	{"idempotency-ttl", "how long a Greet response is replayed for its idempotency key", func(c *Config) interface{} { return &c.Idempotency.TTL }},
+	{"log-level", "lowest level of the messages logged: debug, info or error", func(c *Config) interface{} { return &c.LogLevel }},
+	{"admin", "register the GreetAdmin service; its token is read from GREET_ADMIN_TOKEN or admin.token", func(c *Config) interface{} { return &c.Admin.Enabled }},
+	{"log-requests", "log every RPC with its status and duration at info rather than debug level", func(c *Config) interface{} { return &c.Interceptors.Logging }},
+	{"recover-panics", "turn handler panics into INTERNAL errors", func(c *Config) interface{} { return &c.Interceptors.Recovery }},
+	{"cache", "cache Greet responses by request", func(c *Config) interface{} { return &c.Interceptors.Cache.Enabled }},
+	{"cache-size", "Greet responses kept by the cache", func(c *Config) interface{} { return &c.Interceptors.Cache.Size }},
+	{"cache-ttl", "how long a cached Greet response is served", func(c *Config) interface{} { return &c.Interceptors.Cache.TTL }},
+	{"reflection", "register the gRPC reflection service", func(c *Config) interface{} { return &c.Features.Reflection }},
+	{"http", "REST gateway listen address, e.g. localhost:8080 (disabled when empty)", func(c *Config) interface{} { return &c.Features.HTTPAddr }},
+	{"grpcweb", "gRPC-Web listen address, e.g. localhost:8081 (disabled when empty)", func(c *Config) interface{} { return &c.Features.GRPCWebAddr }},
+	{"cors-origins", "comma separated origins allowed to call gRPC-Web", func(c *Config) interface{} { return &c.Features.CORSOrigins }},
+	{"connect", "also accept the Connect protocol on the gRPC port", func(c *Config) interface{} { return &c.Features.Connect }},
+	{"mux", "serve gRPC, REST, gRPC-Web and Connect together on the gRPC port", func(c *Config) interface{} { return &c.Features.Mux }},
+	{"metrics", "metrics listen address, e.g. localhost:9090 (disabled when empty)", func(c *Config) interface{} { return &c.Features.MetricsAddr }},
+}
+
+// secrets are read from the file and the environment but have no flag, so
+// they never show up in the process list or expvar's cmdline.
+var secrets = []option{
+	{"admin-token", "bearer token GreetAdmin and ResetGreetingCount callers must send", func(c *Config) interface{} { return &c.Admin.Token }},
+}
+
+func (o option) env() string {
+	return "GREET_" + strings.ToUpper(strings.Replace(o.name, "-", "_", -1))
+}
+
+// loadConfig builds the configuration from args and the environment. It
+// reports whether -print-config was given.
+func loadConfig(args []string) (*Config, bool, error) {
+	fs := flag.NewFlagSet("greet_server", flag.ContinueOnError)
+	configFile := fs.String("config", os.Getenv("GREET_CONFIG"), "YAML configuration file")
+	printConfig := fs.Bool("print-config", false, "print the effective configuration and exit")
+
+	// Flags are bound to a separate Config so that only the ones actually
+	// set are layered on top of the file and the environment.
+	flags := defaultConfig()
+	for _, o := range options {
+		switch p := o.field(flags).(type) {
+		case *string:
+			fs.StringVar(p, o.name, *p, o.usage)
+		case *bool:
+			fs.BoolVar(p, o.name, *p, o.usage)
+		case *int:
+			fs.IntVar(p, o.name, *p, o.usage)
+		case *time.Duration:
+			fs.DurationVar(p, o.name, *p, o.usage)
+		}
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, false, err
+	}
+
+	cfg := defaultConfig()
+	if *configFile != "" {
+		if err := cfg.loadFile(*configFile); err != nil {
+			return nil, false, err
+		}
+	}
+	for _, o := range append(options[:len(options):len(options)], secrets...) {
+		v, ok := os.LookupEnv(o.env())
+		if !ok {
+			continue
+		}
+		if err := setValue(o.field(cfg), v); err != nil {
+			return nil, false, fmt.Errorf("%s: %v", o.env(), err)
+		}
+	}
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	for _, o := range options {
+		if set[o.name] {
+			copyValue(o.field(cfg), o.field(flags))
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, false, err
+	}
+	return cfg, *printConfig, nil
+}
+
+func (c *Config) loadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("config file: %v", err)
+	}
+	defer f.Close()
+	dec := yaml.NewDecoder(f)
+	dec.KnownFields(true)
+	if err := dec.Decode(c); err != nil && err != io.EOF {
+		return fmt.Errorf("config file %s: %v", path, err)
+	}
+	return nil
+}
+
+func setValue(dst interface{}, v string) error {
+	switch p := dst.(type) {
+	case *string:
+		*p = v
+	case *bool:
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return err
+		}
+		*p = b
+	case *int:
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return err
+		}
+		*p = n
+	case *time.Duration:
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return err
+		}
+		*p = d
+	}
+	return nil
+}
+
+func copyValue(dst, src interface{}) {
+	switch p := dst.(type) {
+	case *string:
+		*p = *src.(*string)
+	case *bool:
+		*p = *src.(*bool)
+	case *int:
+		*p = *src.(*int)
+	case *time.Duration:
+		*p = *src.(*time.Duration)
+	}
+}
+
+// Validate reports every problem with the configuration at once.
+func (c *Config) Validate() error {
+	var problems []string
+	addr := func(name, value string, required bool) {
+		if value == "" {
+			if required {
+				problems = append(problems, name+" must be set")
+			}
+			return
+		}
+		if _, _, err := net.SplitHostPort(value); err != nil {
+			problems = append(problems, fmt.Sprintf("%s %q: %v", name, value, err))
+		}
+	}
+	addr("addr", c.Addr, true)
+	addr("features.http_addr", c.Features.HTTPAddr, false)
+	addr("features.grpcweb_addr", c.Features.GRPCWebAddr, false)
+	addr("features.metrics_addr", c.Features.MetricsAddr, false)
+	if c.Features.HTTPAddr != "" && c.Features.HTTPAddr == c.Addr {
+		problems = append(problems, "features.http_addr must differ from addr; use features.mux to share the gRPC port")
+	}
+	if c.Features.GRPCWebAddr != "" && c.Features.GRPCWebAddr == c.Addr {
+		problems = append(problems, "features.grpcweb_addr must differ from addr; use features.mux to share the gRPC port")
+	}
+	if c.Features.HTTPAddr != "" && c.Features.HTTPAddr == c.Features.GRPCWebAddr {
+		problems = append(problems, "features.http_addr and features.grpcweb_addr must differ")
+	}
+	if c.Features.MetricsAddr != "" {
+		for _, other := range []string{c.Addr, c.Features.HTTPAddr, c.Features.GRPCWebAddr} {
+			if c.Features.MetricsAddr == other {
+				problems = append(problems, "features.metrics_addr must differ from the other listen addresses")
+				break
+			}
+		}
+	}
+
+	file := func(name, path string) {
+		if path == "" {
+			problems = append(problems, name+" must be set when tls.enabled is true")
+		} else if _, err := os.Stat(path); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", name, err))
+		}
+	}
+	if c.TLS.Enabled {
+		file("tls.cert_file", c.TLS.CertFile)
+		file("tls.key_file", c.TLS.KeyFile)
+		if c.TLS.ClientCAFile != "" {
+			file("tls.client_ca_file", c.TLS.ClientCAFile)
+		}
+	}
+
+	duration := func(name string, d time.Duration) {
+		if d < 0 {
+			problems = append(problems, fmt.Sprintf("%s must not be negative, got %v", name, d))
+		}
+	}
+	duration("tls.reload_interval", c.TLS.ReloadInterval)
+	duration("keepalive.time", c.Keepalive.Time)
+	duration("keepalive.timeout", c.Keepalive.Timeout)
+	duration("keepalive.max_connection_idle", c.Keepalive.MaxConnectionIdle)
+	duration("keepalive.max_connection_age", c.Keepalive.MaxConnectionAge)
+	duration("keepalive.max_connection_age_grace", c.Keepalive.MaxConnectionAgeGrace)
+	duration("keepalive.min_time", c.Keepalive.MinTime)
+	duration("stream_interval", c.StreamInterval)
+	duration("idempotency.ttl", c.Idempotency.TTL)
+	duration("interceptors.cache.ttl", c.Interceptors.Cache.TTL)
+	if c.MaxRecvMsgSize <= 0 {
+		problems = append(problems, fmt.Sprintf("max_recv_msg_size must be positive, got %d", c.MaxRecvMsgSize))
+	}
+	if c.MaxSendMsgSize <= 0 {
+		problems = append(problems, fmt.Sprintf("max_send_msg_size must be positive, got %d", c.MaxSendMsgSize))
+	}
+	if c.EveryOne.QueueSize <= 0 {
+		problems = append(problems, fmt.Sprintf("greet_every_one.queue_size must be positive, got %d", c.EveryOne.QueueSize))
+	}
+	if _, err := greetlog.ParseLevel(c.LogLevel); err != nil {
+		problems = append(problems, fmt.Sprintf("log_level must be debug, info or error, got %q", c.LogLevel))
+	}
+	if c.Admin.Enabled && c.Admin.Token == "" {
+		problems = append(problems, "admin.token must be set when admin.enabled is true")
+	}
+	if c.MaxBatchSize <= 0 {
+		problems = append(problems, fmt.Sprintf("max_batch_size must be positive, got %d", c.MaxBatchSize))
+	}
+	if c.Interceptors.Cache.Enabled && c.Interceptors.Cache.Size <= 0 {
+		problems = append(problems, fmt.Sprintf("interceptors.cache.size must be positive, got %d", c.Interceptors.Cache.Size))
+	}
+	if c.Interceptors.Cache.Enabled && c.Interceptors.Cache.TTL == 0 {
+		problems = append(problems, "interceptors.cache.ttl must be positive when interceptors.cache.enabled is true")
+	}
+	if c.Idempotency.Size < 0 {
+		problems = append(problems, fmt.Sprintf("idempotency.size must not be negative, got %d", c.Idempotency.Size))
+	}
+	if c.Idempotency.Size > 0 && c.Idempotency.TTL == 0 {
+		problems = append(problems, "idempotency.ttl must be positive when idempotency.size is set")
+	}
+	if c.SubscriberBuffer <= 0 {
+		problems = append(problems, fmt.Sprintf("subscriber_buffer must be positive, got %d", c.SubscriberBuffer))
+	}
+	switch c.History.Backend {
+	case "none":
+	case "memory":
+		if c.History.MaxRecords <= 0 {
+			problems = append(problems, fmt.Sprintf("history.max_records must be positive, got %d", c.History.MaxRecords))
+		}
+	case "bolt":
+		if c.History.Path == "" {
+			problems = append(problems, "history.path must be set when history.backend is bolt")
+		}
+		if c.History.MaxRecords < 0 {
+			problems = append(problems, fmt.Sprintf("history.max_records must not be negative, got %d", c.History.MaxRecords))
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("history.backend must be memory, bolt or none, got %q", c.History.Backend))
+	}
+	switch c.Counts.Backend {
+	case "none", "memory":
+	case "bolt":
+		if c.Counts.Path == "" {
+			problems = append(problems, "counts.path must be set when counts.backend is bolt")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("counts.backend must be memory, bolt or none, got %q", c.Counts.Backend))
+	}
+	if !greetserver.QueuePolicy(c.EveryOne.QueuePolicy).Valid() {
+		problems = append(problems, fmt.Sprintf("greet_every_one.queue_policy must be block, drop-oldest or error, got %q", c.EveryOne.QueuePolicy))
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("invalid configuration:\n  %s", strings.Join(problems, "\n  "))
+}
+
+// openStores opens the history and count backends. Both may use the same
+// bolt file, which is then opened once. The returned func closes them.
+func (c *Config) openStores() (greetstore.Store, greetstore.Counter, func(), error) {
+	bolts := map[string]*greetstore.Bolt{}
+	closeAll := func() {
+		for _, b := range bolts {
+			b.Close()
+		}
+	}
+	openBolt := func(path string) (*greetstore.Bolt, error) {
+		if b, ok := bolts[path]; ok {
+			return b, nil
+		}
+		b, err := greetstore.OpenBolt(path, c.History.MaxRecords)
+		if err != nil {
+			closeAll()
+			return nil, err
+		}
+		bolts[path] = b
+		return b, nil
+	}
+
+	var store greetstore.Store
+	switch c.History.Backend {
+	case "memory":
+		store = greetstore.NewMemory(c.History.MaxRecords)
+	case "bolt":
+		b, err := openBolt(c.History.Path)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		store = b
+	}
+	var counter greetstore.Counter
+	switch c.Counts.Backend {
+	case "memory":
+		counter = greetstore.NewMemoryCounter()
+	case "bolt":
+		b, err := openBolt(c.Counts.Path)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		counter = b
+	}
+	return store, counter, closeAll, nil
+}
+
+// redacted returns a copy of c that is safe to show, with the admin token
+// masked.
+func (c *Config) redacted() *Config {
+	r := *c
+	if r.Admin.Token != "" {
+		r.Admin.Token = "REDACTED"
+	}
+	return &r
+}
+
+// String renders the configuration as YAML, in the same layout as the file.
+func (c *Config) String() string {
+	var b strings.Builder
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(2)
+	if err := enc.Encode(c); err != nil {
+		return err.Error()
+	}
+	return b.String()
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// -print-config and GetConfig show the configuration without the admin token.
+func TestRedactedHidesAdminToken(t *testing.T) {
+	t.Setenv("GREET_ADMIN_TOKEN", "s3cret")
+	cfg, _, err := loadConfig([]string{"-admin", "-print-config"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := cfg.redacted().String()
+	if strings.Contains(out, "s3cret") {
+		t.Errorf("redacted configuration shows the token:\n%s", out)
+	}
+	if !strings.Contains(out, "token: REDACTED") {
+		t.Errorf("redacted configuration does not mark the token:\n%s", out)
+	}
+	if cfg.Admin.Token != "s3cret" {
+		t.Errorf("redacted changed the configuration: token = %q", cfg.Admin.Token)
+	}
+
+	cfg.Admin.Token = ""
+	if out := cfg.redacted().String(); strings.Contains(out, "REDACTED") {
+		t.Errorf("an unset token is shown as set:\n%s", out)
+	}
+}
+
+// The admin token has no flag: flags are visible to every local user and in
+// expvar's cmdline.
+func TestAdminTokenIsNotAFlag(t *testing.T) {
+	if _, _, err := loadConfig([]string{"-admin", "-admin-token", "s3cret"}); err == nil {
+		t.Fatal("-admin-token accepted")
+	}
+	t.Setenv("GREET_ADMIN_TOKEN", "s3cret")
+	cfg, _, err := loadConfig([]string{"-admin"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Admin.Token != "s3cret" {
+		t.Errorf("token = %q, want it from GREET_ADMIN_TOKEN", cfg.Admin.Token)
+	}
+}
+
+func TestValidateIdempotencyTTL(t *testing.T) {
+	for _, tc := range []struct {
+		size int
+		ttl  time.Duration
+		ok   bool
+	}{
+		{10, time.Minute, true},
+		{10, 0, false},
+		{10, -time.Minute, false},
+		{0, 0, true},
+	} {
+		cfg := defaultConfig()
+		cfg.Idempotency.Size, cfg.Idempotency.TTL = tc.size, tc.ttl
+		if err := cfg.Validate(); (err == nil) != tc.ok {
+			t.Errorf("size %d, ttl %v: Validate = %v", tc.size, tc.ttl, err)
+		}
+	}
+}
+
+func TestValidateCacheTTL(t *testing.T) {
+	for _, tc := range []struct {
+		enabled bool
+		ttl     time.Duration
+		ok      bool
+	}{
+		{true, time.Minute, true},
+		{true, 0, false},
+		{true, -time.Minute, false},
+		{false, 0, true},
+	} {
+		cfg := defaultConfig()
+		cfg.Interceptors.Cache.Enabled, cfg.Interceptors.Cache.TTL = tc.enabled, tc.ttl
+		if err := cfg.Validate(); (err == nil) != tc.ok {
+			t.Errorf("enabled %v, ttl %v: Validate = %v", tc.enabled, tc.ttl, err)
+		}
+	}
+}
+
+// writeConfig writes a YAML configuration file and returns its path.
+func writeConfig(t *testing.T, yaml string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(yaml), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// Each setting comes from the last source that sets it: defaults, the file,
+// the environment, then the flags.
+func TestLoadConfigPrecedence(t *testing.T) {
+	path := writeConfig(t, `
+addr: localhost:1001
+stream_interval: 2s
+greet_every_one:
+  queue_size: 20
+  queue_policy: drop-oldest
+log_level: error
+`)
+	t.Setenv("GREET_CONFIG", path)
+	t.Setenv("GREET_QUEUE_SIZE", "30")
+	t.Setenv("GREET_LOG_LEVEL", "debug")
+	t.Setenv("GREET_MAX_BATCH_SIZE", "40")
+	t.Setenv("GREET_RECOVER_PANICS", "false")
+	t.Setenv("GREET_CACHE_TTL", "5m")
+	cfg, _, err := loadConfig([]string{"-log-level", "info", "-stream-interval", "3s"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		name      string
+		got, want interface{}
+	}{
+		{"default", cfg.SubscriberBuffer, 64},
+		{"file", cfg.Addr, "localhost:1001"},
+		{"file", cfg.EveryOne.QueuePolicy, "drop-oldest"},
+		{"environment over file", cfg.EveryOne.QueueSize, 30},
+		{"environment over default", cfg.MaxBatchSize, 40},
+		{"environment over default", cfg.Interceptors.Recovery, false},
+		{"environment over default", cfg.Interceptors.Cache.TTL, 5 * time.Minute},
+		{"flag over environment", cfg.LogLevel, "info"},
+		{"flag over file", cfg.StreamInterval, 3 * time.Second},
+	} {
+		if tc.got != tc.want {
+			t.Errorf("%s: got %v, want %v", tc.name, tc.got, tc.want)
+		}
+	}
+
+	// A flag given at its default value still wins, and -config beats
+	// GREET_CONFIG.
+	other := writeConfig(t, "addr: localhost:1002\n")
+	cfg, _, err = loadConfig([]string{"-config", other, "-queue-size", "16"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Addr != "localhost:1002" || cfg.EveryOne.QueueSize != 16 {
+		t.Errorf("addr %s, queue_size %d; want localhost:1002 from -config and 16 from the flag", cfg.Addr, cfg.EveryOne.QueueSize)
+	}
+}
+
+func TestLoadConfigRejectsUnknownKeys(t *testing.T) {
+	for _, yaml := range []string{
+		"adr: localhost:1001\n",
+		"tls:\n  cert: server.crt\n",
+	} {
+		if _, _, err := loadConfig([]string{"-config", writeConfig(t, yaml)}); err == nil || !strings.Contains(err.Error(), "not found") {
+			t.Errorf("%q: %v, want an unknown field error", yaml, err)
+		}
+	}
+}
+
+func TestLoadConfigBadValues(t *testing.T) {
+	if _, _, err := loadConfig([]string{"-config", filepath.Join(t.TempDir(), "missing.yaml")}); err == nil {
+		t.Error("missing config file accepted")
+	}
+	if _, _, err := loadConfig([]string{"-config", writeConfig(t, "max_batch_size: lots\n")}); err == nil {
+		t.Error("a string for an int accepted in the file")
+	}
+	t.Setenv("GREET_QUEUE_SIZE", "lots")
+	if _, _, err := loadConfig(nil); err == nil || !strings.Contains(err.Error(), "GREET_QUEUE_SIZE") {
+		t.Errorf("bad environment variable: %v, want an error naming it", err)
+	}
+}
+
+// Validate reports every problem in one error rather than the first.
+func TestValidateReportsEveryProblem(t *testing.T) {
+	if err := defaultConfig().Validate(); err != nil {
+		t.Fatalf("defaults: %v", err)
+	}
+	cfg := defaultConfig()
+	cfg.Addr = "no port"
+	cfg.Features.HTTPAddr = cfg.Addr
+	cfg.TLS.Enabled = true
+	cfg.TLS.CertFile = ""
+	cfg.Keepalive.Time = -time.Second
+	cfg.LogLevel = "loud"
+	cfg.Admin.Enabled = true
+	cfg.History.Backend = "sql"
+	cfg.Counts.Backend = "bolt"
+	cfg.Counts.Path = ""
+	cfg.EveryOne.QueuePolicy = "wait"
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Validate accepted an invalid configuration")
+	}
+	for _, want := range []string{
+		`addr "no port"`,
+		"features.http_addr must differ from addr",
+		"tls.cert_file must be set",
+		"keepalive.time must not be negative",
+		`log_level must be debug, info or error, got "loud"`,
+		"admin.token must be set",
+		`history.backend must be memory, bolt or none, got "sql"`,
+		"counts.path must be set",
+		`greet_every_one.queue_policy must be block, drop-oldest or error, got "wait"`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error does not report %q:\n%v", want, err)
+		}
+	}
+}
+
+// The example configuration is valid as it is.
+func TestExampleConfig(t *testing.T) {
+	if _, _, err := loadConfig([]string{"-config", "config.yaml"}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestOpenStores(t *testing.T) {
+	dir := t.TempDir()
+	for _, tc := range []struct {
+		name                   string
+		history, counts        string
+		historyPath, countPath string
+		shared                 bool
+	}{
+		{"memory", "memory", "memory", "", "", false},
+		{"none", "none", "none", "", "", false},
+		{"one bolt file", "bolt", "bolt", "greetings.db", "greetings.db", true},
+		{"two bolt files", "bolt", "bolt", "history.db", "counts.db", false},
+		{"bolt history only", "bolt", "memory", "history.db", "", false},
+	} {
+		cfg := defaultConfig()
+		cfg.History.Backend, cfg.History.Path = tc.history, filepath.Join(dir, tc.historyPath)
+		cfg.Counts.Backend, cfg.Counts.Path = tc.counts, filepath.Join(dir, tc.countPath)
+		store, counter, closeStores, err := cfg.openStores()
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if (store == nil) != (tc.history == "none") || (counter == nil) != (tc.counts == "none") {
+			t.Errorf("%s: store %T, counter %T", tc.name, store, counter)
+		}
+		if shared := store != nil && counter != nil && interface{}(store) == interface{}(counter); shared != tc.shared {
+			t.Errorf("%s: one bolt file opened as one store: %v, want %v", tc.name, shared, tc.shared)
+		}
+		// Everything opened is closed again, so the files can be reopened.
+		closeStores()
+	}
+
+	cfg := defaultConfig()
+	cfg.History.Backend, cfg.History.Path = "bolt", filepath.Join(dir, "greetings.db")
+	cfg.Counts.Backend, cfg.Counts.Path = "bolt", filepath.Join(dir, "missing", "counts.db")
+	if _, _, _, err := cfg.openStores(); err == nil {
+		t.Fatal("openStores succeeded with a counts file it cannot create")
+	}
+	// The history file opened before the failure was closed.
+	cfg.Counts.Backend = "memory"
+	_, _, closeStores, err := cfg.openStores()
+	if err != nil {
+		t.Fatalf("history file left open after a failure: %v", err)
+	}
+	closeStores()
+}
@@ -0,0 +1,395 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"../greetpb"
+	"connectrpc.com/connect"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/stats"
+	"google.golang.org/grpc/status"
+)
+
+// connectProtoCodec and connectJSONCodec replace the default Connect codecs,
+// which only accept APIv2 messages, with ones built on golang/protobuf so the
+// generated greetpb types can be used directly.
+type connectProtoCodec struct{}
+
+func (connectProtoCodec) Name() string { return "proto" }
+
+func (connectProtoCodec) Marshal(m interface{}) ([]byte, error) {
+	msg, ok := m.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("%T is not a proto.Message", m)
+	}
+	return proto.Marshal(msg)
+}
+
+func (connectProtoCodec) Unmarshal(data []byte, m interface{}) error {
+	msg, ok := m.(proto.Message)
+	if !ok {
+		return fmt.Errorf("%T is not a proto.Message", m)
+	}
+	return proto.Unmarshal(data, msg)
+}
+
+type connectJSONCodec struct{}
+
+func (connectJSONCodec) Name() string { return "json" }
+
+func (connectJSONCodec) Marshal(m interface{}) ([]byte, error) {
+	msg, ok := m.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("%T is not a proto.Message", m)
+	}
+	var buf bytes.Buffer
+	if err := (&jsonpb.Marshaler{}).Marshal(&buf, msg); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (connectJSONCodec) Unmarshal(data []byte, m interface{}) error {
+	msg, ok := m.(proto.Message)
+	if !ok {
+		return fmt.Errorf("%T is not a proto.Message", m)
+	}
+	if len(data) == 0 {
+		msg.Reset()
+		return nil
+	}
+	return jsonpb.Unmarshal(bytes.NewReader(data), msg)
+}
+
+// connectOptions is what Connect calls share with the grpc.Server, so that
+// they are intercepted, counted and size-limited like gRPC calls. Stats may
+// be nil.
+type connectOptions struct {
+	Unary          []grpc.UnaryServerInterceptor
+	Stream         []grpc.StreamServerInterceptor
+	Stats          stats.Handler
+	MaxRecvMsgSize int
+	MaxSendMsgSize int
+}
+
+// connectServer runs Connect procedures through the gRPC interceptors and
+// stats handler.
+type connectServer struct {
+	impl   greetpb.GreetServiceServer
+	unary  grpc.UnaryServerInterceptor
+	stream grpc.StreamServerInterceptor
+	stats  stats.Handler
+	opts   []connect.HandlerOption
+}
+
+// newConnectHandler exposes impl over the Connect protocol. Each procedure
+// adapts the Connect request or stream to the gRPC server interfaces, so the
+// handlers in greetserver are reused as they are.
+func newConnectHandler(impl greetpb.GreetServiceServer, o connectOptions) http.Handler {
+	c := &connectServer{
+		impl:   impl,
+		unary:  chainUnaryInterceptors(o.Unary),
+		stream: chainStreamInterceptors(o.Stream),
+		stats:  o.Stats,
+		opts: []connect.HandlerOption{
+			connect.WithCodec(connectProtoCodec{}),
+			connect.WithCodec(connectJSONCodec{}),
+			connect.WithReadMaxBytes(o.MaxRecvMsgSize),
+			connect.WithSendMaxBytes(o.MaxSendMsgSize),
+		},
+	}
+	mux := http.NewServeMux()
+
+	// Unary
+	mux.Handle(connectUnary(c, "/greet.GreetService/Greet", impl.Greet))
+
+	// Server Stream
+	mux.Handle(connectStreaming[greetpb.GreetRequest, greetpb.GreetResponse](c, "/greet.GreetService/GreetManyTimes",
+		func(req *greetpb.GreetRequest, ss grpc.ServerStream) error {
+			return impl.GreetManyTimes(req, &connectGreetManyTimesServer{ss})
+		}))
+
+	// Client Stream
+	mux.Handle("/greet.GreetService/LongGreet", connect.NewClientStreamHandler("/greet.GreetService/LongGreet",
+		func(ctx context.Context, stream *connect.ClientStream[greetpb.GreetRequest]) (*connect.Response[greetpb.GreetResponse], error) {
+			res := connect.NewResponse(&greetpb.GreetResponse{})
+			ss := &connectServerStream{
+				ctx:     incomingContext(ctx, stream.Peer(), stream.RequestHeader()),
+				header:  res.Header(),
+				trailer: res.Trailer(),
+				recv: func(m interface{}) error {
+					if !stream.Receive() {
+						if err := stream.Err(); err != nil {
+							return err
+						}
+						return io.EOF
+					}
+					return copyMessage(m, stream.Msg())
+				},
+			}
+			err := c.serveStream(ss, "/greet.GreetService/LongGreet", true, false, func(srv interface{}, ss grpc.ServerStream) error {
+				ls := &connectLongGreetServer{ServerStream: ss}
+				err := impl.LongGreet(ls)
+				if ls.res != nil {
+					res.Msg = ls.res
+				}
+				return err
+			})
+			if err != nil {
+				return nil, err
+			}
+			return res, nil
+		}, c.opts...))
+
+	// Bi-Directional Stream
+	mux.Handle("/greet.GreetService/GreetEveryOne", connect.NewBidiStreamHandler("/greet.GreetService/GreetEveryOne",
+		func(ctx context.Context, stream *connect.BidiStream[greetpb.GreetRequest, greetpb.GreetResponse]) error {
+			ss := &connectServerStream{
+				ctx:     incomingContext(ctx, stream.Peer(), stream.RequestHeader()),
+				header:  stream.ResponseHeader(),
+				trailer: stream.ResponseTrailer(),
+				send: func(m interface{}) error {
+					return stream.Send(m.(*greetpb.GreetResponse))
+				},
+				recv: func(m interface{}) error {
+					req, err := stream.Receive()
+					if err != nil {
+						return err
+					}
+					return copyMessage(m, req)
+				},
+			}
+			return c.serveStream(ss, "/greet.GreetService/GreetEveryOne", true, true, func(srv interface{}, ss grpc.ServerStream) error {
+				return impl.GreetEveryOne(&connectGreetEveryOneServer{ss})
+			})
+		}, c.opts...))
+
+	// Unary
+	mux.Handle(connectUnary(c, "/greet.GreetService/ListGreetings", impl.ListGreetings))
+
+	// Server Stream
+	mux.Handle(connectStreaming[greetpb.SubscribeGreetingsRequest, greetpb.GreetingEvent](c, "/greet.GreetService/SubscribeGreetings",
+		func(req *greetpb.SubscribeGreetingsRequest, ss grpc.ServerStream) error {
+			return impl.SubscribeGreetings(req, &connectSubscribeGreetingsServer{ss})
+		}))
+
+	// Unary
+	mux.Handle(connectUnary(c, "/greet.GreetService/ResetGreetingCount", impl.ResetGreetingCount))
+
+	// Unary
+	mux.Handle(connectUnary(c, "/greet.GreetService/GreetBatch", impl.GreetBatch))
+
+	return mux
+}
+
+// connectUnary returns the pattern and handler of a unary procedure that
+// calls call through the unary interceptors.
+func connectUnary[Req, Res any](c *connectServer, procedure string, call func(context.Context, *Req) (*Res, error)) (string, http.Handler) {
+	return procedure, connect.NewUnaryHandler(procedure,
+		func(ctx context.Context, req *connect.Request[Req]) (*connect.Response[Res], error) {
+			ctx, end := c.begin(incomingContext(ctx, req.Peer(), req.Header()), procedure)
+			info := &grpc.UnaryServerInfo{Server: c.impl, FullMethod: procedure}
+			res, err := c.unary(ctx, req.Msg, info, func(ctx context.Context, m interface{}) (interface{}, error) {
+				return call(ctx, m.(*Req))
+			})
+			end(err)
+			if err != nil {
+				return nil, connectError(err)
+			}
+			return connect.NewResponse(res.(*Res)), nil
+		}, c.opts...)
+}
+
+// connectStreaming returns the pattern and handler of a server streaming
+// procedure that calls call through the stream interceptors.
+func connectStreaming[Req, Res any](c *connectServer, procedure string, call func(*Req, grpc.ServerStream) error) (string, http.Handler) {
+	return procedure, connect.NewServerStreamHandler(procedure,
+		func(ctx context.Context, req *connect.Request[Req], stream *connect.ServerStream[Res]) error {
+			ss := &connectServerStream{
+				ctx:     incomingContext(ctx, req.Peer(), req.Header()),
+				header:  stream.ResponseHeader(),
+				trailer: stream.ResponseTrailer(),
+				send: func(m interface{}) error {
+					return stream.Send(m.(*Res))
+				},
+			}
+			return c.serveStream(ss, procedure, false, true, func(srv interface{}, ss grpc.ServerStream) error {
+				return call(req.Msg, ss)
+			})
+		}, c.opts...)
+}
+
+// serveStream runs handler on ss through the stream interceptors and
+// returns its error as a Connect error.
+func (c *connectServer) serveStream(ss *connectServerStream, procedure string, client, server bool, handler grpc.StreamHandler) error {
+	var end func(error)
+	ss.ctx, end = c.begin(ss.ctx, procedure)
+	info := &grpc.StreamServerInfo{FullMethod: procedure, IsClientStream: client, IsServerStream: server}
+	err := c.stream(c.impl, ss, info, handler)
+	end(err)
+	return connectError(err)
+}
+
+// begin reports the start of a call to the stats handler, if any; the
+// returned func reports its end.
+func (c *connectServer) begin(ctx context.Context, procedure string) (context.Context, func(error)) {
+	if c.stats == nil {
+		return ctx, func(error) {}
+	}
+	ctx = c.stats.TagRPC(ctx, &stats.RPCTagInfo{FullMethodName: procedure})
+	c.stats.HandleRPC(ctx, &stats.Begin{BeginTime: time.Now()})
+	return ctx, func(err error) {
+		c.stats.HandleRPC(ctx, &stats.End{EndTime: time.Now(), Error: err})
+	}
+}
+
+// incomingContext exposes the HTTP request headers as gRPC metadata and the
+// client's address as its peer.
+func incomingContext(ctx context.Context, p connect.Peer, header http.Header) context.Context {
+	md := metadata.MD{}
+	for k, v := range header {
+		md.Append(strings.ToLower(k), v...)
+	}
+	if p.Addr != "" {
+		ctx = peer.NewContext(ctx, &peer.Peer{Addr: connectAddr(p.Addr)})
+	}
+	return metadata.NewIncomingContext(ctx, md)
+}
+
+// connectAddr is the "host:port" of a Connect client as a net.Addr.
+type connectAddr string
+
+func (a connectAddr) Network() string { return "tcp" }
+
+func (a connectAddr) String() string { return string(a) }
+
+// connectError converts a gRPC status error into the equivalent Connect
+// error; the two protocols share their code numbering.
+func connectError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if st, ok := status.FromError(err); ok {
+		return connect.NewError(connect.Code(st.Code()), errors.New(st.Message()))
+	}
+	return err
+}
+
+func copyMessage(dst interface{}, src proto.Message) error {
+	msg, ok := dst.(proto.Message)
+	if !ok {
+		return fmt.Errorf("%T is not a proto.Message", dst)
+	}
+	msg.Reset()
+	proto.Merge(msg, src)
+	return nil
+}
+
+// connectServerStream implements grpc.ServerStream on top of a Connect
+// stream. Headers and trailers set by the handler are written to the
+// Connect response.
+type connectServerStream struct {
+	ctx     context.Context
+	header  http.Header
+	trailer http.Header
+	send    func(m interface{}) error
+	recv    func(m interface{}) error
+}
+
+func (s *connectServerStream) SetHeader(md metadata.MD) error {
+	for k, v := range md {
+		for _, vv := range v {
+			s.header.Add(k, vv)
+		}
+	}
+	return nil
+}
+
+func (s *connectServerStream) SendHeader(md metadata.MD) error {
+	return s.SetHeader(md)
+}
+
+func (s *connectServerStream) SetTrailer(md metadata.MD) {
+	for k, v := range md {
+		for _, vv := range v {
+			s.trailer.Add(k, vv)
+		}
+	}
+}
+
+func (s *connectServerStream) Context() context.Context { return s.ctx }
+
+func (s *connectServerStream) SendMsg(m interface{}) error {
+	if s.send == nil {
+		return errors.New("connect: stream does not support sending")
+	}
+	return s.send(m)
+}
+
+func (s *connectServerStream) RecvMsg(m interface{}) error {
+	if s.recv == nil {
+		return errors.New("connect: stream does not support receiving")
+	}
+	return s.recv(m)
+}
+
+type connectGreetManyTimesServer struct {
+	grpc.ServerStream
+}
+
+func (s *connectGreetManyTimesServer) Send(m *greetpb.GreetResponse) error {
+	return s.SendMsg(m)
+}
+
+type connectSubscribeGreetingsServer struct {
+	grpc.ServerStream
+}
+
+func (s *connectSubscribeGreetingsServer) Send(m *greetpb.GreetingEvent) error {
+	return s.SendMsg(m)
+}
+
+type connectLongGreetServer struct {
+	grpc.ServerStream
+	res *greetpb.GreetResponse
+}
+
+func (s *connectLongGreetServer) SendAndClose(m *greetpb.GreetResponse) error {
+	s.res = m
+	return nil
+}
+
+func (s *connectLongGreetServer) Recv() (*greetpb.GreetRequest, error) {
+	m := new(greetpb.GreetRequest)
+	if err := s.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+type connectGreetEveryOneServer struct {
+	grpc.ServerStream
+}
+
+func (s *connectGreetEveryOneServer) Send(m *greetpb.GreetResponse) error {
+	return s.SendMsg(m)
+}
+
+func (s *connectGreetEveryOneServer) Recv() (*greetpb.GreetRequest, error) {
+	m := new(greetpb.GreetRequest)
+	if err := s.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
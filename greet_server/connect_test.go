@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"../greetpb"
+	"../greetserver"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/stats"
+)
+
+// countingStats is a stats.Handler that counts the calls begun and ended.
+type countingStats struct {
+	mu         sync.Mutex
+	begin, end int
+}
+
+func (s *countingStats) TagRPC(ctx context.Context, _ *stats.RPCTagInfo) context.Context { return ctx }
+
+func (s *countingStats) HandleRPC(ctx context.Context, rs stats.RPCStats) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch rs.(type) {
+	case *stats.Begin:
+		s.begin++
+	case *stats.End:
+		s.end++
+	}
+}
+
+func (s *countingStats) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+func (s *countingStats) HandleConn(context.Context, stats.ConnStats) {}
+
+func postConnect(t *testing.T, url, contentType, body string) (int, []byte) {
+	t.Helper()
+	res, err := http.Post(url, contentType, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	b, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res.StatusCode, b
+}
+
+func TestConnectUnaryRunsInterceptors(t *testing.T) {
+	var methods []string
+	var caller string
+	st := &countingStats{}
+	ts := httptest.NewServer(newConnectHandler(&greetserver.Server{}, connectOptions{
+		Unary: []grpc.UnaryServerInterceptor{
+			func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+				methods = append(methods, info.FullMethod)
+				if p, ok := peer.FromContext(ctx); ok {
+					caller = p.Addr.String()
+				}
+				return handler(ctx, req)
+			},
+		},
+		Stats:          st,
+		MaxRecvMsgSize: 1024,
+		MaxSendMsgSize: 1024,
+	}))
+	defer ts.Close()
+
+	code, body := postConnect(t, ts.URL+"/greet.GreetService/Greet", "application/json",
+		`{"greeting":{"first_name":"Nanda","last_name":"R"}}`)
+	if code != http.StatusOK {
+		t.Fatalf("HTTP %d: %s", code, body)
+	}
+	var res struct{ Result string }
+	if err := json.Unmarshal(body, &res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Result != "Hi Nanda R" {
+		t.Errorf("result = %q, want %q", res.Result, "Hi Nanda R")
+	}
+	if len(methods) != 1 || methods[0] != "/greet.GreetService/Greet" {
+		t.Errorf("interceptor saw %v, want one Greet call", methods)
+	}
+	if !strings.HasPrefix(caller, "127.0.0.1:") {
+		t.Errorf("peer = %q, want the client's address", caller)
+	}
+	if st.begin != 1 || st.end != 1 {
+		t.Errorf("stats begin/end = %d/%d, want 1/1", st.begin, st.end)
+	}
+}
+
+func TestConnectStreamRunsInterceptors(t *testing.T) {
+	var infos []grpc.StreamServerInfo
+	ts := httptest.NewServer(newConnectHandler(&greetserver.Server{}, connectOptions{
+		Stream: []grpc.StreamServerInterceptor{
+			func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+				infos = append(infos, *info)
+				return handler(srv, ss)
+			},
+		},
+	}))
+	defer ts.Close()
+
+	msg := []byte(`{"greeting":{"first_name":"Nanda","last_name":"R"}}`)
+	env := make([]byte, 5, 5+len(msg))
+	binary.BigEndian.PutUint32(env[1:], uint32(len(msg)))
+	env = append(env, msg...)
+	code, body := postConnect(t, ts.URL+"/greet.GreetService/GreetManyTimes", "application/connect+json", string(env))
+	if code != http.StatusOK {
+		t.Fatalf("HTTP %d: %s", code, body)
+	}
+	var messages int
+	for len(body) >= 5 {
+		flag, n := body[0], int(binary.BigEndian.Uint32(body[1:5]))
+		if flag&0x02 == 0 {
+			messages++
+		} else if strings.Contains(string(body[5:5+n]), "error") {
+			t.Errorf("end of stream: %s", body[5:5+n])
+		}
+		body = body[5+n:]
+	}
+	if messages != 10 {
+		t.Errorf("got %d messages, want 10", messages)
+	}
+	if len(infos) != 1 || infos[0].FullMethod != "/greet.GreetService/GreetManyTimes" || !infos[0].IsServerStream || infos[0].IsClientStream {
+		t.Errorf("interceptor saw %+v, want one server-streaming GreetManyTimes call", infos)
+	}
+}
+
+func TestConnectMaxRecvMsgSize(t *testing.T) {
+	ts := httptest.NewServer(newConnectHandler(&greetserver.Server{}, connectOptions{MaxRecvMsgSize: 64}))
+	defer ts.Close()
+
+	req := &greetpb.GreetRequest{Greeting: &greetpb.Greeting{FirstName: strings.Repeat("x", 200)}}
+	b, err := json.Marshal(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, body := postConnect(t, ts.URL+"/greet.GreetService/Greet", "application/json", string(b))
+	if code == http.StatusOK {
+		t.Fatalf("oversized request accepted: %s", body)
+	}
+	if !strings.Contains(string(body), "resource_exhausted") {
+		t.Errorf("body = %s, want resource_exhausted", body)
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+
+	"../greetpb"
+	"github.com/grpc-ecosystem/grpc-gateway/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newGatewayHandler returns the REST/JSON reverse proxy forwarding to conn.
+func newGatewayHandler(ctx context.Context, conn *grpc.ClientConn) (http.Handler, error) {
+	mux := runtime.NewServeMux()
+	if err := greetpb.RegisterGreetServiceHandler(ctx, mux, conn); err != nil {
+		return nil, err
+	}
+	return mux, nil
+}
+
+// dialGateway returns the connection the REST gateway forwards to impl on.
+// It is an in-process connection to s, unless s only accepts TLS (secure):
+// the in-memory connection has none, so the gateway then gets a GreetService
+// server of its own, built from options, which must leave the credentials
+// out.
+func dialGateway(ctx context.Context, s *grpc.Server, secure bool, impl greetpb.GreetServiceServer, options []grpc.ServerOption) (*grpc.ClientConn, error) {
+	if secure {
+		s = grpc.NewServer(options...)
+		greetpb.RegisterGreetServiceServer(s, impl)
+	}
+	return dialInProcess(ctx, s)
+}
+
+// dialInProcess serves s on an in-memory listener and returns a connection
+// to it, so the HTTP front ends reach the gRPC handlers (and interceptors)
+// without going through the public listener or its TLS settings.
+func dialInProcess(ctx context.Context, s *grpc.Server) (*grpc.ClientConn, error) {
+	lis := bufconn.Listen(1024 * 1024)
+	go func() {
+		_ = s.Serve(lis)
+	}()
+	dialer := func(ctx context.Context, _ string) (net.Conn, error) {
+		return lis.DialContext(ctx)
+	}
+	return grpc.DialContext(ctx, "inprocess", grpc.WithContextDialer(dialer), grpc.WithInsecure())
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"../greetpb"
+	"../greetserver"
+	"../greetstore"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// The REST gateway records the address of the HTTP client it saw, not one
+// the client claims in X-Forwarded-For.
+func TestGatewayCallerIgnoresSpoofedForwardedFor(t *testing.T) {
+	s := grpc.NewServer()
+	impl := &greetserver.Server{Store: greetstore.NewMemory(10)}
+	greetpb.RegisterGreetServiceServer(s, impl)
+	defer s.Stop()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	conn, err := dialInProcess(ctx, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	gateway, err := newGatewayHandler(ctx, conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(gateway)
+	defer ts.Close()
+
+	req, err := http.NewRequest(http.MethodPost, ts.URL+"/v1/greet", strings.NewReader(`{"greeting":{"first_name":"Nanda"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Forwarded-For", "203.0.113.1")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("POST /v1/greet: HTTP %d", res.StatusCode)
+	}
+
+	list, err := impl.ListGreetings(ctx, &greetpb.ListGreetingsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.GetGreetings()) != 1 {
+		t.Fatalf("%d greetings recorded, want 1", len(list.GetGreetings()))
+	}
+	if got := list.GetGreetings()[0].GetCaller(); got != "127.0.0.1" {
+		t.Errorf("caller = %q, want the HTTP client's address 127.0.0.1", got)
+	}
+}
+
+// With -tls and -http, the gRPC server only accepts TLS, but the gateway's
+// in-process connection has none; its calls must still go through, and
+// through the same interceptors.
+func TestGatewayWithTLS(t *testing.T) {
+	certFile, keyFile, _ := writeTestCert(t)
+	certs, err := newCertReloader(certFile, keyFile, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var methods []string
+	options := []grpc.ServerOption{grpc.ChainUnaryInterceptor(
+		func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			methods = append(methods, info.FullMethod)
+			return handler(ctx, req)
+		},
+	)}
+	s := grpc.NewServer(append(options, grpc.Creds(credentials.NewTLS(certs.TLSConfig())))...)
+	impl := &greetserver.Server{}
+	greetpb.RegisterGreetServiceServer(s, impl)
+	defer s.Stop()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	conn, err := dialGateway(ctx, s, true, impl, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	gateway, err := newGatewayHandler(ctx, conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(gateway)
+	defer ts.Close()
+
+	res, err := http.Post(ts.URL+"/v1/greet", "application/json", strings.NewReader(`{"greeting":{"first_name":"Nanda","last_name":"R"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK || !strings.Contains(string(body), "Hi Nanda R") {
+		t.Fatalf("POST /v1/greet: HTTP %d: %s", res.StatusCode, body)
+	}
+	if len(methods) != 1 || methods[0] != "/greet.GreetService/Greet" {
+		t.Errorf("interceptor saw %v, want one Greet call", methods)
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"../greetlog"
+	"github.com/improbable-eng/grpc-web/go/grpcweb"
+	"google.golang.org/grpc"
+)
+
+// grpcWebAllowHeaders matches the allow_headers of the Envoy CORS config in
+// grpc_web_envoy_proxy.md. grpc-status and grpc-message are exposed to the
+// browser by the grpcweb wrapper itself.
+var grpcWebAllowHeaders = []string{
+	"keep-alive",
+	"user-agent",
+	"cache-control",
+	"content-type",
+	"content-transfer-encoding",
+	"x-accept-content-transfer-encoding",
+	"x-accept-response-streaming",
+	"x-user-agent",
+	"x-grpc-web",
+	"grpc-timeout",
+}
+
+// newGRPCWebHandler wraps s so browsers can call it with gRPC-Web, in both
+// binary (application/grpc-web) and text (application/grpc-web-text) modes.
+// origins is a comma separated list of allowed CORS origins, "*" allows any.
+// Requests that are not gRPC-Web are passed to next, or rejected with 404
+// when next is nil.
+func newGRPCWebHandler(s *grpc.Server, origins string, next http.Handler) http.Handler {
+	allowed := map[string]bool{}
+	for _, origin := range strings.Split(origins, ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			allowed[origin] = true
+		}
+	}
+	wrapped := grpcweb.WrapServer(s,
+		grpcweb.WithOriginFunc(func(origin string) bool {
+			return allowed["*"] || allowed[origin]
+		}),
+		grpcweb.WithAllowedRequestHeaders(grpcWebAllowHeaders),
+	)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if wrapped.IsGrpcWebRequest(r) || wrapped.IsAcceptableGrpcCorsRequest(r) {
+			wrapped.ServeHTTP(w, r)
+			return
+		}
+		if next == nil {
+			http.NotFound(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// serveGRPCWeb serves gRPC-Web over HTTP/1.1 on lis.
+func serveGRPCWeb(s *grpc.Server, lis net.Listener, origins string) error {
+	greetlog.Infof("gRPC-Web listening on %s..!!", lis.Addr())
+	return http.Serve(lis, newGRPCWebHandler(s, origins, nil))
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"../greetpb"
+	"../greetserver"
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/grpc"
+)
+
+const testOrigin = "https://greet.example"
+
+func newGRPCWebTestServer(t *testing.T, next http.Handler) *httptest.Server {
+	t.Helper()
+	s := grpc.NewServer()
+	greetpb.RegisterGreetServiceServer(s, &greetserver.Server{})
+	ts := httptest.NewServer(newGRPCWebHandler(s, testOrigin, next))
+	t.Cleanup(func() {
+		ts.Close()
+		s.Stop()
+	})
+	return ts
+}
+
+// grpcWebFrame prefixes msg with the gRPC-Web frame header: a flag byte,
+// 0 for data and 0x80 for trailers, and the big-endian length.
+func grpcWebFrame(flag byte, msg []byte) []byte {
+	b := make([]byte, 5, 5+len(msg))
+	b[0] = flag
+	binary.BigEndian.PutUint32(b[1:], uint32(len(msg)))
+	return append(b, msg...)
+}
+
+// grpcWebResponse is a decoded gRPC-Web response body.
+type grpcWebResponse struct {
+	messages [][]byte
+	trailer  http.Header
+}
+
+func parseGRPCWebFrames(t *testing.T, body []byte) grpcWebResponse {
+	t.Helper()
+	res := grpcWebResponse{trailer: http.Header{}}
+	for len(body) > 0 {
+		if len(body) < 5 {
+			t.Fatalf("truncated frame header: %q", body)
+		}
+		flag, n := body[0], int(binary.BigEndian.Uint32(body[1:5]))
+		if len(body) < 5+n {
+			t.Fatalf("frame of %d bytes, only %d left", n, len(body)-5)
+		}
+		payload := body[5 : 5+n]
+		body = body[5+n:]
+		if flag&0x80 == 0 {
+			res.messages = append(res.messages, payload)
+			continue
+		}
+		for _, line := range strings.Split(string(payload), "\r\n") {
+			if i := strings.Index(line, ":"); i > 0 {
+				res.trailer.Add(strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+1:]))
+			}
+		}
+	}
+	return res
+}
+
+// decodeGRPCWebText decodes a grpc-web-text body, which may be several
+// padded base64 chunks back to back, one per flush.
+func decodeGRPCWebText(t *testing.T, body []byte) []byte {
+	t.Helper()
+	var out, chunk []byte
+	for i := 0; i+4 <= len(body); i += 4 {
+		group := body[i : i+4]
+		chunk = append(chunk, group...)
+		if bytes.IndexByte(group, '=') >= 0 || i+4 == len(body) {
+			b, err := base64.StdEncoding.DecodeString(string(chunk))
+			if err != nil {
+				t.Fatalf("decoding grpc-web-text %q: %v", chunk, err)
+			}
+			out = append(out, b...)
+			chunk = nil
+		}
+	}
+	return out
+}
+
+func callGRPCWeb(t *testing.T, url, method string, req proto.Message, text bool) (*http.Response, grpcWebResponse) {
+	t.Helper()
+	b, err := proto.Marshal(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, contentType := grpcWebFrame(0, b), "application/grpc-web+proto"
+	if text {
+		body, contentType = []byte(base64.StdEncoding.EncodeToString(body)), "application/grpc-web-text"
+	}
+	httpReq, err := http.NewRequest(http.MethodPost, url+method, bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpReq.Header.Set("Content-Type", contentType)
+	httpReq.Header.Set("X-Grpc-Web", "1")
+	httpReq.Header.Set("Origin", testOrigin)
+	httpRes, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		t.Fatalf("POST %s: %v", method, err)
+	}
+	defer httpRes.Body.Close()
+	if httpRes.StatusCode != http.StatusOK {
+		t.Fatalf("POST %s: HTTP %d", method, httpRes.StatusCode)
+	}
+	raw, err := ioutil.ReadAll(httpRes.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text {
+		if ct := httpRes.Header.Get("Content-Type"); !strings.HasPrefix(ct, "application/grpc-web-text") {
+			t.Fatalf("Content-Type = %q, want application/grpc-web-text", ct)
+		}
+		raw = decodeGRPCWebText(t, raw)
+	}
+	return httpRes, parseGRPCWebFrames(t, raw)
+}
+
+// grpcStatus returns the grpc-status of a response, which is sent as a
+// header when the call failed before any message and as a trailer frame
+// otherwise.
+func grpcStatus(httpRes *http.Response, res grpcWebResponse) string {
+	if s := res.trailer.Get("grpc-status"); s != "" {
+		return s
+	}
+	return httpRes.Header.Get("grpc-status")
+}
+
+func TestGRPCWebGreet(t *testing.T) {
+	ts := newGRPCWebTestServer(t, nil)
+	for _, text := range []bool{false, true} {
+		t.Run(fmt.Sprintf("text=%v", text), func(t *testing.T) {
+			req := &greetpb.GreetRequest{Greeting: &greetpb.Greeting{FirstName: "Nanda", LastName: "R"}}
+			httpRes, res := callGRPCWeb(t, ts.URL, "/greet.GreetService/Greet", req, text)
+			if got := grpcStatus(httpRes, res); got != "0" {
+				t.Fatalf("grpc-status = %q, want 0 (grpc-message %q)", got, res.trailer.Get("grpc-message"))
+			}
+			if len(res.messages) != 1 {
+				t.Fatalf("got %d messages, want 1", len(res.messages))
+			}
+			var out greetpb.GreetResponse
+			if err := proto.Unmarshal(res.messages[0], &out); err != nil {
+				t.Fatal(err)
+			}
+			if out.GetResult() != "Hi Nanda R" {
+				t.Errorf("Result = %q, want %q", out.GetResult(), "Hi Nanda R")
+			}
+			if got := httpRes.Header.Get("Access-Control-Allow-Origin"); got != testOrigin {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, testOrigin)
+			}
+		})
+	}
+}
+
+func TestGRPCWebGreetManyTimes(t *testing.T) {
+	ts := newGRPCWebTestServer(t, nil)
+	for _, text := range []bool{false, true} {
+		t.Run(fmt.Sprintf("text=%v", text), func(t *testing.T) {
+			req := &greetpb.GreetRequest{Greeting: &greetpb.Greeting{FirstName: "Nanda", LastName: "R"}}
+			httpRes, res := callGRPCWeb(t, ts.URL, "/greet.GreetService/GreetManyTimes", req, text)
+			if got := grpcStatus(httpRes, res); got != "0" {
+				t.Fatalf("grpc-status = %q, want 0", got)
+			}
+			if len(res.messages) != 10 {
+				t.Fatalf("got %d messages, want 10", len(res.messages))
+			}
+			for i, m := range res.messages {
+				var out greetpb.GreetResponse
+				if err := proto.Unmarshal(m, &out); err != nil {
+					t.Fatal(err)
+				}
+				if want := fmt.Sprintf("Hello Nanda R : number = %d", i); out.GetResult() != want {
+					t.Errorf("message %d = %q, want %q", i, out.GetResult(), want)
+				}
+			}
+		})
+	}
+}
+
+func TestGRPCWebError(t *testing.T) {
+	ts := newGRPCWebTestServer(t, nil)
+	req := &greetpb.GreetRequest{Greeting: &greetpb.Greeting{FirstName: "Nanda"}, ResumeToken: "bad"}
+	httpRes, res := callGRPCWeb(t, ts.URL, "/greet.GreetService/GreetManyTimes", req, false)
+	if got := grpcStatus(httpRes, res); got != "3" {
+		t.Fatalf("grpc-status = %q, want 3 (INVALID_ARGUMENT)", got)
+	}
+}
+
+func TestGRPCWebCORSPreflight(t *testing.T) {
+	ts := newGRPCWebTestServer(t, nil)
+	preflight := func(origin string) *http.Response {
+		req, err := http.NewRequest(http.MethodOptions, ts.URL+"/greet.GreetService/Greet", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", "POST")
+		req.Header.Set("Access-Control-Request-Headers", "content-type,x-grpc-web,x-user-agent")
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.Copy(ioutil.Discard, res.Body)
+		res.Body.Close()
+		return res
+	}
+
+	res := preflight(testOrigin)
+	if res.StatusCode >= 300 {
+		t.Fatalf("preflight: HTTP %d", res.StatusCode)
+	}
+	if got := res.Header.Get("Access-Control-Allow-Origin"); got != testOrigin {
+		t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, testOrigin)
+	}
+	allowed := strings.ToLower(res.Header.Get("Access-Control-Allow-Headers"))
+	for _, h := range []string{"content-type", "x-grpc-web", "x-user-agent"} {
+		if !strings.Contains(allowed, h) {
+			t.Errorf("Access-Control-Allow-Headers = %q, missing %s", allowed, h)
+		}
+	}
+
+	res = preflight("https://evil.example")
+	if got := res.Header.Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("Access-Control-Allow-Origin for a foreign origin = %q, want none", got)
+	}
+}
+
+func TestGRPCWebPassesOtherRequests(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "next")
+	})
+	ts := newGRPCWebTestServer(t, next)
+	res, err := http.Get(ts.URL + "/v1/greetings")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	b, _ := ioutil.ReadAll(res.Body)
+	if string(b) != "next" {
+		t.Errorf("body = %q, want the next handler's", b)
+	}
+
+	ts = newGRPCWebTestServer(t, nil)
+	if res, err = http.Get(ts.URL + "/v1/greetings"); err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusNotFound {
+		t.Errorf("without next: HTTP %d, want 404", res.StatusCode)
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"expvar"
+	"runtime/debug"
+	"time"
+
+	"../greetlog"
+	"../greetserver"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// serverInterceptors returns the interceptors enabled in cfg, outermost
+// first.
+func serverInterceptors(cfg InterceptorConfig) ([]grpc.UnaryServerInterceptor, []grpc.StreamServerInterceptor) {
+	var unary []grpc.UnaryServerInterceptor
+	var stream []grpc.StreamServerInterceptor
+	// Every RPC is logged at debug level, or at info level with Logging.
+	level := greetlog.Debug
+	if cfg.Logging {
+		level = greetlog.Info
+	}
+	unary = append(unary, loggingUnaryInterceptor(level))
+	stream = append(stream, loggingStreamInterceptor(level))
+	if cfg.Recovery {
+		unary = append(unary, recoveryUnaryInterceptor)
+		stream = append(stream, recoveryStreamInterceptor)
+	}
+	if cfg.Cache.Enabled {
+		cache := greetserver.NewResponseCache(cfg.Cache.Size, cfg.Cache.TTL)
+		expvar.Publish("greet_cache", cache)
+		unary = append(unary, cache.UnaryServerInterceptor)
+	}
+	return unary, stream
+}
+
+func loggingUnaryInterceptor(level greetlog.Level) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		res, err := handler(ctx, req)
+		greetlog.Logf(level, "%s %v %v", info.FullMethod, status.Code(err), time.Since(start))
+		return res, err
+	}
+}
+
+func loggingStreamInterceptor(level greetlog.Level) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		greetlog.Logf(level, "%s %v %v", info.FullMethod, status.Code(err), time.Since(start))
+		return err
+	}
+}
+
+func recoveryUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (res interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			greetlog.Errorf("Panic in %s : %v\n%s", info.FullMethod, r, debug.Stack())
+			err = status.Errorf(codes.Internal, "internal error")
+		}
+	}()
+	return handler(ctx, req)
+}
+
+func recoveryStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			greetlog.Errorf("Panic in %s : %v\n%s", info.FullMethod, r, debug.Stack())
+			err = status.Errorf(codes.Internal, "internal error")
+		}
+	}()
+	return handler(srv, ss)
+}
+
+// chainUnaryInterceptors composes interceptors, outermost first, like
+// grpc.ChainUnaryInterceptor, for calls that reach the handlers without
+// going through the grpc.Server.
+func chainUnaryInterceptors(interceptors []grpc.UnaryServerInterceptor) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		for i := len(interceptors) - 1; i >= 0; i-- {
+			interceptor, next := interceptors[i], handler
+			handler = func(ctx context.Context, req interface{}) (interface{}, error) {
+				return interceptor(ctx, req, info, next)
+			}
+		}
+		return handler(ctx, req)
+	}
+}
+
+// chainStreamInterceptors is chainUnaryInterceptors for streams.
+func chainStreamInterceptors(interceptors []grpc.StreamServerInterceptor) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		for i := len(interceptors) - 1; i >= 0; i-- {
+			interceptor, next := interceptors[i], handler
+			handler = func(srv interface{}, ss grpc.ServerStream) error {
+				return interceptor(srv, ss, info, next)
+			}
+		}
+		return handler(srv, ss)
+	}
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"../greetpb"
+	"../greetserver"
+	"golang.org/x/net/http2"
+	"google.golang.org/grpc"
+)
+
+// startKeepalive serves greetserver.Server with the keepalive settings of
+// cfg and returns its address and the tracker of its open connections.
+func startKeepalive(t *testing.T, cfg KeepaliveConfig, impl *greetserver.Server) (string, *connTracker) {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conns := newConnTracker()
+	s := grpc.NewServer(append(keepaliveOptions(cfg), grpc.StatsHandler(conns))...)
+	greetpb.RegisterGreetServiceServer(s, impl)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+	return lis.Addr().String(), conns
+}
+
+// waitConns waits up to timeout for the server to have n open connections.
+func waitConns(conns *connTracker, n int, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if len(conns.peers()) == n {
+			return true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return len(conns.peers()) == n
+}
+
+func TestMaxConnectionIdle(t *testing.T) {
+	addr, conns := startKeepalive(t, KeepaliveConfig{MaxConnectionIdle: 200 * time.Millisecond},
+		&greetserver.Server{Interval: 100 * time.Millisecond})
+	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	client := greetpb.NewGreetServiceClient(conn)
+	req := &greetpb.GreetRequest{Greeting: &greetpb.Greeting{FirstName: "Nanda", LastName: "R"}}
+
+	// A stream outliving the idle timeout keeps the connection busy.
+	stream, err := client.GreetManyTimes(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := 0
+	for {
+		if _, err := stream.Recv(); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("Recv %d: %v", n, err)
+		}
+		n++
+	}
+	if n != 10 {
+		t.Fatalf("got %d responses over ~1s, want 10: the connection was closed while busy", n)
+	}
+	if len(conns.peers()) != 1 {
+		t.Fatalf("%d open connections after the stream, want 1", len(conns.peers()))
+	}
+
+	// Once idle, the server closes it.
+	if !waitConns(conns, 0, 2*time.Second) {
+		t.Fatalf("idle connection still open: %d", len(conns.peers()))
+	}
+
+	// The client reconnects transparently for the next call.
+	if _, err := client.Greet(context.Background(), req); err != nil {
+		t.Fatalf("Greet after the idle close: %v", err)
+	}
+}
+
+// pingServer opens a raw HTTP/2 connection to addr and sends count pings,
+// wait apart, without ever starting a stream. It returns the GOAWAY the
+// server answered with, if any, and the number of ping ACKs received.
+func pingServer(t *testing.T, addr string, count int, wait time.Duration) (*http2.GoAwayFrame, int) {
+	t.Helper()
+	c, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if _, err := io.WriteString(c, http2.ClientPreface); err != nil {
+		t.Fatal(err)
+	}
+	framer := http2.NewFramer(c, c)
+	var wmu sync.Mutex // the framer's writes are not safe for concurrent use
+	if err := framer.WriteSettings(); err != nil {
+		t.Fatal(err)
+	}
+
+	type result struct {
+		goAway *http2.GoAwayFrame
+		acks   int
+	}
+	done := make(chan result, 1)
+	go func() {
+		var r result
+		defer func() { done <- r }()
+		for {
+			f, err := framer.ReadFrame()
+			if err != nil {
+				return
+			}
+			switch f := f.(type) {
+			case *http2.SettingsFrame:
+				if !f.IsAck() {
+					wmu.Lock()
+					framer.WriteSettingsAck()
+					wmu.Unlock()
+				}
+			case *http2.PingFrame:
+				if f.IsAck() {
+					r.acks++
+				}
+			case *http2.GoAwayFrame:
+				r.goAway = f
+				return
+			}
+		}
+	}()
+	for i := 0; i < count; i++ {
+		wmu.Lock()
+		err := framer.WritePing(false, [8]byte{byte(i)})
+		wmu.Unlock()
+		if err != nil {
+			break
+		}
+		time.Sleep(wait)
+	}
+	c.SetReadDeadline(time.Now().Add(500 * time.Millisecond))
+	r := <-done
+	return r.goAway, r.acks
+}
+
+func TestKeepaliveTooManyPings(t *testing.T) {
+	addr, _ := startKeepalive(t, KeepaliveConfig{MinTime: time.Minute}, &greetserver.Server{})
+	goAway, _ := pingServer(t, addr, 5, 10*time.Millisecond)
+	if goAway == nil {
+		t.Fatal("no GOAWAY for a client pinging every 10ms with min_time 1m")
+	}
+	if goAway.ErrCode != http2.ErrCodeEnhanceYourCalm || string(goAway.DebugData()) != "too_many_pings" {
+		t.Errorf("GOAWAY %v %q, want ENHANCE_YOUR_CALM too_many_pings", goAway.ErrCode, goAway.DebugData())
+	}
+}
+
+func TestKeepalivePermittedPings(t *testing.T) {
+	addr, _ := startKeepalive(t, KeepaliveConfig{MinTime: 10 * time.Millisecond, PermitWithoutStream: true}, &greetserver.Server{})
+	goAway, acks := pingServer(t, addr, 5, 50*time.Millisecond)
+	if goAway != nil {
+		t.Fatalf("GOAWAY %v %q for pings within the policy", goAway.ErrCode, goAway.DebugData())
+	}
+	if acks != 5 {
+		t.Errorf("%d ping ACKs, want 5", acks)
+	}
+}
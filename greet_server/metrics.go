@@ -0,0 +1,20 @@
+package main
+
+import (
+	"expvar"
+	"net/http"
+
+	"../greetlog"
+)
+
+// metricsPath is where the expvar metrics are served on the metrics address.
+// They are never served on the gRPC port: expvar also publishes the command
+// line and memory statistics, which are not for every client to see.
+const metricsPath = "/debug/vars"
+
+func serveMetrics(addr string) error {
+	mux := http.NewServeMux()
+	mux.Handle(metricsPath, expvar.Handler())
+	greetlog.Infof("Metrics listening on %s%s..!!", addr, metricsPath)
+	return http.ListenAndServe(addr, mux)
+}
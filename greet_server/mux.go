@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+
+	"../greetlog"
+	"github.com/soheilhy/cmux"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"golang.org/x/net/http2/hpack"
+	"google.golang.org/grpc"
+)
+
+// serveMux serves gRPC and HTTP on a single listener. HTTP/2 connections
+// whose first request carries a gRPC content-type are handed to s; all other
+// traffic, HTTP/1.1 or h2c, is served by handler. TLS, when enabled, is
+// terminated on lis before the protocol is detected, so both sides share it.
+func serveMux(lis net.Listener, s *grpc.Server, handler http.Handler) error {
+	m := cmux.New(lis)
+	http1L := m.Match(cmux.HTTP1Fast())
+	grpcL := m.MatchWithWriters(matchGRPC)
+	h2cL := m.Match(cmux.Any())
+
+	hs := &http.Server{Handler: h2c.NewHandler(handler, &http2.Server{})}
+	go func() {
+		if err := s.Serve(grpcL); err != nil {
+			greetlog.Errorf("gRPC listener stopped : %v", err)
+		}
+	}()
+	go func() {
+		if err := hs.Serve(http1L); err != nil {
+			greetlog.Errorf("HTTP listener stopped : %v", err)
+		}
+	}()
+	go func() {
+		if err := hs.Serve(settingsAckListener{h2cL}); err != nil {
+			greetlog.Errorf("h2c listener stopped : %v", err)
+		}
+	}()
+	return m.Serve()
+}
+
+// muxHandler is the HTTP side of serveMux: gRPC-Web, then the REST gateway,
+// then Connect for everything else.
+func muxHandler(s *grpc.Server, origins string, gateway, connect http.Handler) http.Handler {
+	return newGRPCWebHandler(s, origins, routeGateway(gateway, connect))
+}
+
+// matchGRPC reports whether an HTTP/2 connection's first request has a gRPC
+// content-type ("application/grpc" or "application/grpc+<codec>"; gRPC-Web is
+// left to the HTTP side). Like cmux's HTTP2MatchHeaderFieldSendSettings it
+// answers the client's SETTINGS, since gRPC clients wait for them before
+// sending headers, but it does so only once and declares
+// SETTINGS_NO_RFC7540_PRIORITIES like the HTTP/2 server that takes over
+// unmatched connections, since clients reject a later change to it.
+func matchGRPC(w io.Writer, r io.Reader) bool {
+	preface := make([]byte, len(http2.ClientPreface))
+	if _, err := io.ReadFull(r, preface); err != nil || !bytes.Equal(preface, []byte(http2.ClientPreface)) {
+		return false
+	}
+	framer := http2.NewFramer(w, r)
+	matched, done := false, false
+	hdec := hpack.NewDecoder(4<<10, func(hf hpack.HeaderField) {
+		if hf.Name == "content-type" {
+			matched = hf.Value == "application/grpc" || strings.HasPrefix(hf.Value, "application/grpc+")
+		}
+	})
+	sentSettings := false
+	for !done {
+		f, err := framer.ReadFrame()
+		if err != nil {
+			return false
+		}
+		switch f := f.(type) {
+		case *http2.SettingsFrame:
+			if !f.IsAck() && !sentSettings {
+				if err := framer.WriteSettings(http2.Setting{ID: http2.SettingNoRFC7540Priorities, Val: 1}); err != nil {
+					return false
+				}
+				sentSettings = true
+			}
+		case *http2.HeadersFrame:
+			if _, err := hdec.Write(f.HeaderBlockFragment()); err != nil {
+				return false
+			}
+			done = f.HeadersEnded()
+		case *http2.ContinuationFrame:
+			if _, err := hdec.Write(f.HeaderBlockFragment()); err != nil {
+				return false
+			}
+			done = f.HeadersEnded()
+		}
+	}
+	return matched
+}
+
+// routeGateway sends REST paths to gateway and everything else to next.
+func routeGateway(gateway, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/v1/") {
+			gateway.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// settingsAckListener wraps HTTP/2 connections that went through matchGRPC
+// without matching. The matcher has already sent the client a SETTINGS frame,
+// and the client's ACK for it would be rejected by the HTTP/2 server as
+// unexpected, so the first SETTINGS ACK is dropped.
+type settingsAckListener struct {
+	net.Listener
+}
+
+func (l settingsAckListener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return &settingsAckConn{Conn: c}, nil
+}
+
+const (
+	frameHeaderLen    = 9
+	frameTypeSettings = 0x4
+	frameFlagAck      = 0x1
+)
+
+type settingsAckConn struct {
+	net.Conn
+	preface bool
+	dropped bool
+	pending []byte
+}
+
+func (c *settingsAckConn) Read(p []byte) (int, error) {
+	if c.dropped && len(c.pending) == 0 {
+		return c.Conn.Read(p)
+	}
+	if !c.dropped {
+		if err := c.fill(); err != nil && len(c.pending) == 0 {
+			return 0, err
+		}
+	}
+	n := copy(p, c.pending)
+	c.pending = c.pending[n:]
+	return n, nil
+}
+
+// fill reads the client preface, then whole frames, into pending until the
+// first SETTINGS ACK has been seen and discarded.
+func (c *settingsAckConn) fill() error {
+	if !c.preface {
+		buf := make([]byte, len(http2.ClientPreface))
+		if _, err := io.ReadFull(c.Conn, buf); err != nil {
+			c.pending = append(c.pending, buf...)
+			c.dropped = true
+			return err
+		}
+		c.preface = true
+		c.pending = append(c.pending, buf...)
+		if !bytes.Equal(buf, []byte(http2.ClientPreface)) {
+			// Not HTTP/2, so no SETTINGS were sent; pass everything through.
+			c.dropped = true
+		}
+		return nil
+	}
+	header := make([]byte, frameHeaderLen)
+	if _, err := io.ReadFull(c.Conn, header); err != nil {
+		c.pending = append(c.pending, header...)
+		c.dropped = true
+		return err
+	}
+	length := int(header[0])<<16 | int(header[1])<<8 | int(header[2])
+	if header[3] == frameTypeSettings && header[4]&frameFlagAck != 0 && length == 0 {
+		c.dropped = true
+		return nil
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.Conn, payload); err != nil {
+		c.pending = append(c.pending, header...)
+		c.pending = append(c.pending, payload...)
+		c.dropped = true
+		return err
+	}
+	c.pending = append(c.pending, header...)
+	c.pending = append(c.pending, payload...)
+	return nil
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"../greetpb"
+	"../greetserver"
+	"github.com/golang/protobuf/proto"
+	"golang.org/x/net/http2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// muxServer is greet_server in -mux mode on a local port.
+type muxServer struct {
+	addr string
+	// client trusts the server certificate; nil without TLS.
+	tls *tls.Config
+}
+
+// startMux serves gRPC, REST, gRPC-Web and Connect on one listener the way
+// main does with -mux, with TLS terminated on the listener when withTLS.
+func startMux(t *testing.T, withTLS bool) *muxServer {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ms := &muxServer{addr: lis.Addr().String()}
+	if withTLS {
+		certFile, keyFile, pool := writeTestCert(t)
+		certs, err := newCertReloader(certFile, keyFile, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		lis = tls.NewListener(lis, certs.TLSConfig("h2", "http/1.1"))
+		ms.tls = &tls.Config{RootCAs: pool, ServerName: "localhost"}
+	}
+
+	s := grpc.NewServer()
+	impl := &greetserver.Server{}
+	greetpb.RegisterGreetServiceServer(s, impl)
+	ctx, cancel := context.WithCancel(context.Background())
+	conn, err := dialInProcess(ctx, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gateway, err := newGatewayHandler(ctx, conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := muxHandler(s, testOrigin, gateway, newConnectHandler(impl, connectOptions{}))
+	go serveMux(lis, s, h)
+	t.Cleanup(func() {
+		cancel()
+		conn.Close()
+		lis.Close()
+		s.Stop()
+	})
+	return ms
+}
+
+// writeTestCert writes a self-signed certificate for localhost and
+// 127.0.0.1, usable by servers and clients, and returns its files and a pool
+// trusting it.
+func writeTestCert(t *testing.T) (certFile, keyFile string, pool *x509.CertPool) {
+	t.Helper()
+	dir := t.TempDir()
+	certFile, keyFile = filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.pem")
+	certPEM, keyPEM, cert := newTestCert(t)
+	writeFile(t, certFile, certPEM)
+	writeFile(t, keyFile, keyPEM)
+	pool = x509.NewCertPool()
+	pool.AddCert(cert)
+	return certFile, keyFile, pool
+}
+
+// newTestCert makes the certificate written by writeTestCert and returns it
+// and its key PEM encoded.
+func newTestCert(t *testing.T) (certPEM, keyPEM []byte, cert *x509.Certificate) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err = x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return certPEM, keyPEM, cert
+}
+
+func writeFile(t *testing.T, name string, data []byte) {
+	t.Helper()
+	if err := os.WriteFile(name, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (ms *muxServer) url(path string) string {
+	if ms.tls != nil {
+		return "https://" + ms.addr + path
+	}
+	return "http://" + ms.addr + path
+}
+
+// http1Client only speaks HTTP/1.1, over TLS when the server uses it.
+func (ms *muxServer) http1Client() *http.Client {
+	tr := &http.Transport{TLSNextProto: map[string]func(string, *tls.Conn) http.RoundTripper{}}
+	if ms.tls != nil {
+		cfg := ms.tls.Clone()
+		cfg.NextProtos = []string{"http/1.1"}
+		tr.TLSClientConfig = cfg
+	}
+	return &http.Client{Transport: tr}
+}
+
+// http2Client speaks HTTP/2: negotiated with ALPN over TLS, h2c otherwise.
+func (ms *muxServer) http2Client() *http.Client {
+	tr := &http2.Transport{TLSClientConfig: ms.tls}
+	if ms.tls == nil {
+		tr.AllowHTTP = true
+		tr.DialTLSContext = func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, addr)
+		}
+	}
+	return &http.Client{Transport: tr}
+}
+
+func TestMux(t *testing.T) {
+	for _, withTLS := range []bool{false, true} {
+		t.Run(fmt.Sprintf("tls=%v", withTLS), func(t *testing.T) {
+			ms := startMux(t, withTLS)
+			t.Run("gRPC", func(t *testing.T) { testMuxGRPC(t, ms) })
+			t.Run("REST", func(t *testing.T) { testMuxREST(t, ms) })
+			t.Run("gRPC-Web", func(t *testing.T) { testMuxGRPCWeb(t, ms) })
+			t.Run("Connect", func(t *testing.T) { testMuxConnect(t, ms) })
+			t.Run("no metrics", func(t *testing.T) { testMuxNoMetrics(t, ms) })
+		})
+	}
+}
+
+func testMuxGRPC(t *testing.T, ms *muxServer) {
+	creds := grpc.WithInsecure()
+	if ms.tls != nil {
+		creds = grpc.WithTransportCredentials(credentials.NewTLS(ms.tls))
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, err := grpc.DialContext(ctx, ms.addr, creds, grpc.WithBlock())
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer conn.Close()
+	res, err := greetpb.NewGreetServiceClient(conn).Greet(ctx, &greetpb.GreetRequest{
+		Greeting: &greetpb.Greeting{FirstName: "Nanda", LastName: "R"},
+	})
+	if err != nil {
+		t.Fatalf("Greet: %v", err)
+	}
+	if res.GetResult() != "Hi Nanda R" {
+		t.Errorf("Result = %q, want %q", res.GetResult(), "Hi Nanda R")
+	}
+}
+
+func testMuxREST(t *testing.T, ms *muxServer) {
+	res, err := ms.http1Client().Post(ms.url("/v1/greet"), "application/json",
+		strings.NewReader(`{"greeting":{"first_name":"Nanda","last_name":"R"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if res.ProtoMajor != 1 {
+		t.Errorf("served over %s, want HTTP/1.1", res.Proto)
+	}
+	body, _ := ioutil.ReadAll(res.Body)
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("HTTP %d: %s", res.StatusCode, body)
+	}
+	if !strings.Contains(string(body), "Hi Nanda R") {
+		t.Errorf("body = %s, want the greeting", body)
+	}
+}
+
+// The expvar metrics include the command line, so they stay off the
+// public port.
+func testMuxNoMetrics(t *testing.T, ms *muxServer) {
+	res, err := ms.http1Client().Get(ms.url(metricsPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	body, _ := ioutil.ReadAll(res.Body)
+	if res.StatusCode == http.StatusOK || strings.Contains(string(body), "cmdline") {
+		t.Errorf("GET %s: HTTP %d: %s", metricsPath, res.StatusCode, body)
+	}
+}
+
+func testMuxGRPCWeb(t *testing.T, ms *muxServer) {
+	b, err := proto.Marshal(&greetpb.GreetRequest{Greeting: &greetpb.Greeting{FirstName: "Nanda", LastName: "R"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := http.NewRequest(http.MethodPost, ms.url("/greet.GreetService/Greet"), bytes.NewReader(grpcWebFrame(0, b)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/grpc-web+proto")
+	req.Header.Set("X-Grpc-Web", "1")
+	res, err := ms.http1Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	raw, _ := ioutil.ReadAll(res.Body)
+	frames := parseGRPCWebFrames(t, raw)
+	if got := grpcStatus(res, frames); got != "0" {
+		t.Fatalf("grpc-status = %q, want 0", got)
+	}
+	var out greetpb.GreetResponse
+	if len(frames.messages) != 1 || proto.Unmarshal(frames.messages[0], &out) != nil {
+		t.Fatalf("got %d messages, want one GreetResponse", len(frames.messages))
+	}
+	if out.GetResult() != "Hi Nanda R" {
+		t.Errorf("Result = %q, want %q", out.GetResult(), "Hi Nanda R")
+	}
+}
+
+func testMuxConnect(t *testing.T, ms *muxServer) {
+	res, err := ms.http2Client().Post(ms.url("/greet.GreetService/Greet"), "application/json",
+		strings.NewReader(`{"greeting":{"first_name":"Nanda","last_name":"R"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if res.ProtoMajor != 2 {
+		t.Errorf("served over %s, want HTTP/2", res.Proto)
+	}
+	body, _ := ioutil.ReadAll(res.Body)
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("HTTP %d: %s", res.StatusCode, body)
+	}
+	var out struct{ Result string }
+	if err := json.Unmarshal(body, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Result != "Hi Nanda R" {
+		t.Errorf("result = %q, want %q", out.Result, "Hi Nanda R")
+	}
+}
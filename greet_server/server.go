@@ -2,100 +2,214 @@ package main
 
 import (
 	"context"
-	"google.golang.org/grpc/credentials"
-	"google.golang.org/grpc/reflection"
-	"io"
+	"crypto/tls"
+	"expvar"
+	"flag"
+	"fmt"
 	"log"
 	"net"
-	"strconv"
+	"net/http"
+	"os"
 	"time"
 
+	"../greetlog"
 	"../greetpb"
+	"../greetserver"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/reflection"
 )
 
-type server struct{}
-
-// Unary
-func (*server) Greet(ctx context.Context, req *greetpb.GreetRequest) (*greetpb.GreetResponse, error) {
-	log.Printf("Greet function invoked  with %v", req)
-	return &greetpb.GreetResponse{Result: "Hi " + req.GetGreeting().GetFirstName() + " " + req.GetGreeting().GetLastName()}, nil
-}
-
-// Server Stream
-func (*server) GreetManyTimes(req *greetpb.GreetRequest, stream greetpb.GreetService_GreetManyTimesServer) error {
-	log.Printf("GreetManyTimes function invoked  with %v", req)
-	for i := 0; i < 10; i++ {
-		res := &greetpb.GreetResponse{
-			Result: "Hello " + req.GetGreeting().GetFirstName() + " " + req.GetGreeting().GetLastName() + " : number = " + strconv.Itoa(i),
-		}
-		_ = stream.Send(res)
-		time.Sleep(1000 * time.Millisecond)
+func main() {
+	started := time.Now()
+	cfg, printConfig, err := loadConfig(os.Args[1:])
+	if err == flag.ErrHelp {
+		return
 	}
-	return nil
-}
+	if err != nil {
+		log.Fatalf("Config Error: %v", err)
+	}
+	if printConfig {
+		fmt.Print(cfg.redacted())
+		return
+	}
+	level, _ := greetlog.ParseLevel(cfg.LogLevel)
+	greetlog.SetLevel(level)
 
-// Client Streaming
-func (*server) LongGreet(reqStream greetpb.GreetService_LongGreetServer) error{
-	log.Printf("LongGreet function invoked  with client streaming..!!")
-	result := ""
-	for {
-		req, err := reqStream.Recv()
-		if err == io.EOF {
-			_ = reqStream.SendAndClose(&greetpb.GreetResponse{Result: result})
-			return nil
-		}
+	lis, err := net.Listen("tcp", cfg.Addr)
+	if err != nil {
+		log.Fatalf("Failed to listen : %v", err)
+	}
+	shared := cfg.Features.Mux || cfg.Features.Connect
+	options := []grpc.ServerOption{
+		grpc.MaxRecvMsgSize(cfg.MaxRecvMsgSize),
+		grpc.MaxSendMsgSize(cfg.MaxSendMsgSize),
+	}
+	options = append(options, keepaliveOptions(cfg.Keepalive)...)
+	unary, stream := serverInterceptors(cfg.Interceptors)
+	var conns *connTracker
+	adminToken := ""
+	if cfg.Admin.Enabled {
+		conns = newConnTracker()
+		options = append(options, grpc.StatsHandler(conns))
+		adminToken = cfg.Admin.Token
+	}
+	// Installed even without -admin, so admin-only calls are refused.
+	unary = append(unary, adminUnaryInterceptor(adminToken))
+	stream = append(stream, adminStreamInterceptor(adminToken))
+	options = append(options, grpc.ChainUnaryInterceptor(unary...), grpc.ChainStreamInterceptor(stream...))
+	var creds []grpc.ServerOption
+	// sideTLS secures the separate REST and gRPC-Web listeners like addr.
+	var sideTLS *tls.Config
+	if cfg.TLS.Enabled {
+		certs, err := newCertReloader(cfg.TLS.CertFile, cfg.TLS.KeyFile, cfg.TLS.ClientCAFile)
 		if err != nil {
-			return err
+			log.Fatalf("SSL file err : %v", err)
 		}
-		result += "Hello " + req.GetGreeting().GetFirstName() + "! "
-	}
-}
-
-// Bi-Directional Streaming
-func (*server) GreetEveryOne(stream greetpb.GreetService_GreetEveryOneServer) error {
-	log.Printf("GreetEveryOne function invoked  with client and server streaming..!!")
-	for {
-		req, err := stream.Recv()
-		if err == io.EOF {
-			return nil
+		if cfg.TLS.ReloadInterval > 0 {
+			go certs.watch(cfg.TLS.ReloadInterval, nil)
 		}
-		if err != nil {
-			log.Printf("Error while calling LongGreet function : %v", err)
-			return err
+		sideTLS = certs.TLSConfig("http/1.1")
+		if shared {
+			// TLS is terminated on the listener and shared by every protocol.
+			lis = tls.NewListener(lis, certs.TLSConfig("h2", "http/1.1"))
+		} else {
+			creds = append(creds, grpc.Creds(credentials.NewTLS(certs.TLSConfig())))
 		}
-		result := "Hello " + req.GetGreeting().GetFirstName() + "! "
-		_ = stream.Send(&greetpb.GreetResponse{Result: result})
 	}
-}
 
+	s := grpc.NewServer(append(options, creds...)...)
 
-func main() {
-	lis, err := net.Listen("tcp", "localhost:50051")
+	// Greeting
+	store, counter, closeStores, err := cfg.openStores()
 	if err != nil {
-		log.Fatalf("Failed to listen : %v", err)
+		log.Fatalf("Store Error: %v", err)
+	}
+	defer closeStores()
+	impl := &greetserver.Server{
+		Interval:         cfg.StreamInterval,
+		QueueSize:        cfg.EveryOne.QueueSize,
+		QueuePolicy:      greetserver.QueuePolicy(cfg.EveryOne.QueuePolicy),
+		Metrics:          &greetserver.StreamMetrics{},
+		SubscriberBuffer: cfg.SubscriberBuffer,
+		MaxBatchSize:     cfg.MaxBatchSize,
+		Store:            store,
+		Counter:          counter,
 	}
-	var options []grpc.ServerOption
-	tls := false
-	if tls{
-		certFile := "greet/ssl/server.crt"
-		keyFile := "greet/ssl/server.pem"
+	if cfg.Idempotency.Size > 0 {
+		impl.Idempotency = greetserver.NewIdempotencyCache(cfg.Idempotency.Size, cfg.Idempotency.TTL)
+	}
+	greetpb.RegisterGreetServiceServer(s, impl)
+	expvar.Publish("greet_every_one", impl.Metrics)
 
-		creds, err:= credentials.NewServerTLSFromFile(certFile,keyFile)
-		if err != nil{
-			log.Fatalf("SSL file err : %v", err)
-		}
-		options = append(options, grpc.Creds(creds))
+	greetlog.Infof("Greeting service run successfully..!!")
+	if cfg.Admin.Enabled {
+		greetpb.RegisterGreetAdminServer(s, &adminServer{cfg: cfg, started: started, conns: conns})
+		greetlog.Infof("Admin service run successfully..!!")
+	}
+	if cfg.Features.Reflection {
+		reflection.Register(s)
 	}
 
-	s := grpc.NewServer()
+	ctx := context.Background()
+	var gateway http.Handler
+	if cfg.Features.HTTPAddr != "" || cfg.Features.Mux {
+		conn, err := dialGateway(ctx, s, len(creds) > 0, impl, options)
+		if err != nil {
+			log.Fatalf("Gateway Error: %v", err)
+		}
+		defer conn.Close()
+		if gateway, err = newGatewayHandler(ctx, conn); err != nil {
+			log.Fatalf("Gateway Error: %v", err)
+		}
+	}
+	if cfg.Features.HTTPAddr != "" {
+		restLis, err := listenHTTP(cfg.Features.HTTPAddr, sideTLS)
+		if err != nil {
+			log.Fatalf("Gateway Error: %v", err)
+		}
+		go func() {
+			greetlog.Infof("REST gateway listening on %s..!!", cfg.Features.HTTPAddr)
+			if err := http.Serve(restLis, gateway); err != nil {
+				log.Fatalf("Gateway Error: %v", err)
+			}
+		}()
+	}
+	if cfg.Features.GRPCWebAddr != "" {
+		webLis, err := listenHTTP(cfg.Features.GRPCWebAddr, sideTLS)
+		if err != nil {
+			log.Fatalf("gRPC-Web Error: %v", err)
+		}
+		go func() {
+			if err := serveGRPCWeb(s, webLis, cfg.Features.CORSOrigins); err != nil {
+				log.Fatalf("gRPC-Web Error: %v", err)
+			}
+		}()
+	}
 
-	// Greeting
-	greetpb.RegisterGreetServiceServer(s, &server{})
+	if cfg.Features.MetricsAddr != "" {
+		go func() {
+			if err := serveMetrics(cfg.Features.MetricsAddr); err != nil {
+				log.Fatalf("Metrics Error: %v", err)
+			}
+		}()
+	}
 
-	log.Print("Greeting service run successfully..!!")
-	reflection.Register(s)
+	if shared {
+		o := connectOptions{
+			Unary:          unary,
+			Stream:         stream,
+			MaxRecvMsgSize: cfg.MaxRecvMsgSize,
+			MaxSendMsgSize: cfg.MaxSendMsgSize,
+		}
+		if conns != nil {
+			o.Stats = conns
+		}
+		var h http.Handler = newConnectHandler(impl, o)
+		if cfg.Features.Mux {
+			h = muxHandler(s, cfg.Features.CORSOrigins, gateway, h)
+			greetlog.Infof("REST, gRPC-Web and Connect enabled on the gRPC port..!!")
+		} else {
+			greetlog.Infof("Connect protocol enabled on the gRPC port..!!")
+		}
+		if err := serveMux(lis, s, h); err != nil {
+			log.Fatalf("Server Error: %v", err)
+		}
+		return
+	}
 	if err := s.Serve(lis); err != nil {
 		log.Fatalf("Server Error: %v", err)
 	}
 }
+
+// listenHTTP listens on addr for one of the separate HTTP front ends,
+// terminating TLS with config when it is not nil, so that they require the
+// same certificates as the gRPC port.
+func listenHTTP(addr string, config *tls.Config) (net.Listener, error) {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	if config != nil {
+		lis = tls.NewListener(lis, config)
+	}
+	return lis, nil
+}
+
+// keepaliveOptions returns the server options for cfg.
+func keepaliveOptions(cfg KeepaliveConfig) []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.KeepaliveParams(keepalive.ServerParameters{
+			Time:                  cfg.Time,
+			Timeout:               cfg.Timeout,
+			MaxConnectionIdle:     cfg.MaxConnectionIdle,
+			MaxConnectionAge:      cfg.MaxConnectionAge,
+			MaxConnectionAgeGrace: cfg.MaxConnectionAgeGrace,
+		}),
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             cfg.MinTime,
+			PermitWithoutStream: cfg.PermitWithoutStream,
+		}),
+	}
+}
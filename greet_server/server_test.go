@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"net/http"
+	"strings"
+	"testing"
+
+	"../greetpb"
+	"../greetserver"
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/grpc"
+)
+
+// With -tls and -client-ca, the separate REST and gRPC-Web listeners
+// require a client certificate just like the gRPC port.
+func TestSideListenersRequireClientCert(t *testing.T) {
+	serverCert, serverKey, serverPool := writeTestCert(t)
+	clientCert, clientKey, _ := writeTestCert(t)
+	certs, err := newCertReloader(serverCert, serverKey, clientCert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := grpc.NewServer()
+	impl := &greetserver.Server{}
+	greetpb.RegisterGreetServiceServer(s, impl)
+	defer s.Stop()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	conn, err := dialInProcess(ctx, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	gateway, err := newGatewayHandler(ctx, conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	restLis, err := listenHTTP("127.0.0.1:0", certs.TLSConfig("http/1.1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer restLis.Close()
+	go http.Serve(restLis, gateway)
+	webLis, err := listenHTTP("127.0.0.1:0", certs.TLSConfig("http/1.1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer webLis.Close()
+	go serveGRPCWeb(s, webLis, testOrigin)
+
+	msg, err := proto.Marshal(&greetpb.GreetRequest{Greeting: &greetpb.Greeting{FirstName: "Nanda", LastName: "R"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	requests := map[string]func(scheme string) *http.Request{
+		"REST": func(scheme string) *http.Request {
+			req, _ := http.NewRequest(http.MethodPost, scheme+"://"+restLis.Addr().String()+"/v1/greet",
+				strings.NewReader(`{"greeting":{"first_name":"Nanda","last_name":"R"}}`))
+			req.Header.Set("Content-Type", "application/json")
+			return req
+		},
+		"gRPC-Web": func(scheme string) *http.Request {
+			req, _ := http.NewRequest(http.MethodPost, scheme+"://"+webLis.Addr().String()+"/greet.GreetService/Greet",
+				bytes.NewReader(grpcWebFrame(0, msg)))
+			req.Header.Set("Content-Type", "application/grpc-web+proto")
+			req.Header.Set("X-Grpc-Web", "1")
+			return req
+		},
+	}
+	pair, err := tls.LoadX509KeyPair(clientCert, clientKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, request := range requests {
+		t.Run(name, func(t *testing.T) {
+			for _, tc := range []struct {
+				name   string
+				scheme string
+				tls    *tls.Config
+				ok     bool
+			}{
+				{"plaintext", "http", nil, false},
+				{"no client certificate", "https", &tls.Config{RootCAs: serverPool}, false},
+				{"client certificate", "https", &tls.Config{RootCAs: serverPool, Certificates: []tls.Certificate{pair}}, true},
+			} {
+				client := &http.Client{Transport: &http.Transport{TLSClientConfig: tc.tls}}
+				res, err := client.Do(request(tc.scheme))
+				if err != nil {
+					if tc.ok {
+						t.Errorf("%s: %v", tc.name, err)
+					}
+					continue
+				}
+				res.Body.Close()
+				if ok := res.StatusCode == http.StatusOK; ok != tc.ok {
+					t.Errorf("%s: HTTP %d", tc.name, res.StatusCode)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+// Package greetlog writes greet_server's log messages through the standard
+// log package, dropping those below a level that can be changed while the
+// server runs.
+package greetlog
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync/atomic"
+)
+
+// Level orders log messages. The values match greetpb.LogLevel.
+type Level int32
+
+const (
+	// Debug also covers a line for every RPC.
+	Debug Level = iota + 1
+	Info
+	Error
+)
+
+var level = int32(Info)
+
+// SetLevel changes the level and returns the previous one.
+func SetLevel(l Level) Level {
+	return Level(atomic.SwapInt32(&level, int32(l)))
+}
+
+// CurrentLevel returns the level messages are written at.
+func CurrentLevel() Level {
+	return Level(atomic.LoadInt32(&level))
+}
+
+// Enabled reports whether messages at l are written.
+func Enabled(l Level) bool {
+	return l >= CurrentLevel()
+}
+
+// ParseLevel accepts debug, info or error.
+func ParseLevel(s string) (Level, error) {
+	switch strings.ToLower(s) {
+	case "debug":
+		return Debug, nil
+	case "info":
+		return Info, nil
+	case "error":
+		return Error, nil
+	}
+	return 0, fmt.Errorf("unknown log level %q", s)
+}
+
+func (l Level) String() string {
+	switch l {
+	case Debug:
+		return "debug"
+	case Info:
+		return "info"
+	case Error:
+		return "error"
+	}
+	return fmt.Sprintf("Level(%d)", int32(l))
+}
+
+// Logf writes the message if l is enabled.
+func Logf(l Level, format string, v ...interface{}) { logf(l, format, v...) }
+
+func Debugf(format string, v ...interface{}) { logf(Debug, format, v...) }
+
+func Infof(format string, v ...interface{}) { logf(Info, format, v...) }
+
+func Errorf(format string, v ...interface{}) { logf(Error, format, v...) }
+
+func logf(l Level, format string, v ...interface{}) {
+	if Enabled(l) {
+		log.Output(3, fmt.Sprintf(format, v...))
+	}
+}
@@ -0,0 +1,708 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: admin.proto
+
+package greetpb
+
+import (
+	context "context"
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+// LogLevel orders greet_server's log messages; those below the current
+// level are not written.
+type LogLevel int32
+
+const (
+	LogLevel_LOG_LEVEL_UNSPECIFIED LogLevel = 0
+	// Also logs every RPC with its status and duration.
+	LogLevel_LOG_LEVEL_DEBUG LogLevel = 1
+	LogLevel_LOG_LEVEL_INFO  LogLevel = 2
+	// Only errors.
+	LogLevel_LOG_LEVEL_ERROR LogLevel = 3
+)
+
+var LogLevel_name = map[int32]string{
+	0: "LOG_LEVEL_UNSPECIFIED",
+	1: "LOG_LEVEL_DEBUG",
+	2: "LOG_LEVEL_INFO",
+	3: "LOG_LEVEL_ERROR",
+}
+
+var LogLevel_value = map[string]int32{
+	"LOG_LEVEL_UNSPECIFIED": 0,
+	"LOG_LEVEL_DEBUG":       1,
+	"LOG_LEVEL_INFO":        2,
+	"LOG_LEVEL_ERROR":       3,
+}
+
+func (x LogLevel) String() string {
+	return proto.EnumName(LogLevel_name, int32(x))
+}
+
+func (LogLevel) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_73a7fc70dcc2027c, []int{0}
+}
+
+type GetServerStatusRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetServerStatusRequest) Reset()         { *m = GetServerStatusRequest{} }
+func (m *GetServerStatusRequest) String() string { return proto.CompactTextString(m) }
+func (*GetServerStatusRequest) ProtoMessage()    {}
+func (*GetServerStatusRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_73a7fc70dcc2027c, []int{0}
+}
+
+func (m *GetServerStatusRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetServerStatusRequest.Unmarshal(m, b)
+}
+func (m *GetServerStatusRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetServerStatusRequest.Marshal(b, m, deterministic)
+}
+func (m *GetServerStatusRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetServerStatusRequest.Merge(m, src)
+}
+func (m *GetServerStatusRequest) XXX_Size() int {
+	return xxx_messageInfo_GetServerStatusRequest.Size(m)
+}
+func (m *GetServerStatusRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetServerStatusRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetServerStatusRequest proto.InternalMessageInfo
+
+type ServerStatus struct {
+	Version   string                 `protobuf:"bytes,1,opt,name=version,proto3" json:"version,omitempty"`
+	GoVersion string                 `protobuf:"bytes,2,opt,name=go_version,json=goVersion,proto3" json:"go_version,omitempty"`
+	StartTime *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=start_time,json=startTime,proto3" json:"start_time,omitempty"`
+	Uptime    *durationpb.Duration   `protobuf:"bytes,4,opt,name=uptime,proto3" json:"uptime,omitempty"`
+	// Calls in progress by full method name, e.g.
+	// "/greet.GreetService/GreetEveryOne".
+	ActiveStreams        map[string]int64 `protobuf:"bytes,5,rep,name=active_streams,json=activeStreams,proto3" json:"active_streams,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
+	LogLevel             LogLevel         `protobuf:"varint,6,opt,name=log_level,json=logLevel,proto3,enum=greet.LogLevel" json:"log_level,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}         `json:"-"`
+	XXX_unrecognized     []byte           `json:"-"`
+	XXX_sizecache        int32            `json:"-"`
+}
+
+func (m *ServerStatus) Reset()         { *m = ServerStatus{} }
+func (m *ServerStatus) String() string { return proto.CompactTextString(m) }
+func (*ServerStatus) ProtoMessage()    {}
+func (*ServerStatus) Descriptor() ([]byte, []int) {
+	return fileDescriptor_73a7fc70dcc2027c, []int{1}
+}
+
+func (m *ServerStatus) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ServerStatus.Unmarshal(m, b)
+}
+func (m *ServerStatus) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ServerStatus.Marshal(b, m, deterministic)
+}
+func (m *ServerStatus) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ServerStatus.Merge(m, src)
+}
+func (m *ServerStatus) XXX_Size() int {
+	return xxx_messageInfo_ServerStatus.Size(m)
+}
+func (m *ServerStatus) XXX_DiscardUnknown() {
+	xxx_messageInfo_ServerStatus.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ServerStatus proto.InternalMessageInfo
+
+func (m *ServerStatus) GetVersion() string {
+	if m != nil {
+		return m.Version
+	}
+	return ""
+}
+
+func (m *ServerStatus) GetGoVersion() string {
+	if m != nil {
+		return m.GoVersion
+	}
+	return ""
+}
+
+func (m *ServerStatus) GetStartTime() *timestamppb.Timestamp {
+	if m != nil {
+		return m.StartTime
+	}
+	return nil
+}
+
+func (m *ServerStatus) GetUptime() *durationpb.Duration {
+	if m != nil {
+		return m.Uptime
+	}
+	return nil
+}
+
+func (m *ServerStatus) GetActiveStreams() map[string]int64 {
+	if m != nil {
+		return m.ActiveStreams
+	}
+	return nil
+}
+
+func (m *ServerStatus) GetLogLevel() LogLevel {
+	if m != nil {
+		return m.LogLevel
+	}
+	return LogLevel_LOG_LEVEL_UNSPECIFIED
+}
+
+type ListPeersRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListPeersRequest) Reset()         { *m = ListPeersRequest{} }
+func (m *ListPeersRequest) String() string { return proto.CompactTextString(m) }
+func (*ListPeersRequest) ProtoMessage()    {}
+func (*ListPeersRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_73a7fc70dcc2027c, []int{2}
+}
+
+func (m *ListPeersRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListPeersRequest.Unmarshal(m, b)
+}
+func (m *ListPeersRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListPeersRequest.Marshal(b, m, deterministic)
+}
+func (m *ListPeersRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListPeersRequest.Merge(m, src)
+}
+func (m *ListPeersRequest) XXX_Size() int {
+	return xxx_messageInfo_ListPeersRequest.Size(m)
+}
+func (m *ListPeersRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListPeersRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListPeersRequest proto.InternalMessageInfo
+
+// PeerInfo is a client connection to the gRPC server.
+type PeerInfo struct {
+	Address string `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	// Common name of the client's TLS certificate, when it presented one.
+	Identity             string                 `protobuf:"bytes,2,opt,name=identity,proto3" json:"identity,omitempty"`
+	ConnectedTime        *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=connected_time,json=connectedTime,proto3" json:"connected_time,omitempty"`
+	ActiveStreams        int64                  `protobuf:"varint,4,opt,name=active_streams,json=activeStreams,proto3" json:"active_streams,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}               `json:"-"`
+	XXX_unrecognized     []byte                 `json:"-"`
+	XXX_sizecache        int32                  `json:"-"`
+}
+
+func (m *PeerInfo) Reset()         { *m = PeerInfo{} }
+func (m *PeerInfo) String() string { return proto.CompactTextString(m) }
+func (*PeerInfo) ProtoMessage()    {}
+func (*PeerInfo) Descriptor() ([]byte, []int) {
+	return fileDescriptor_73a7fc70dcc2027c, []int{3}
+}
+
+func (m *PeerInfo) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PeerInfo.Unmarshal(m, b)
+}
+func (m *PeerInfo) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PeerInfo.Marshal(b, m, deterministic)
+}
+func (m *PeerInfo) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PeerInfo.Merge(m, src)
+}
+func (m *PeerInfo) XXX_Size() int {
+	return xxx_messageInfo_PeerInfo.Size(m)
+}
+func (m *PeerInfo) XXX_DiscardUnknown() {
+	xxx_messageInfo_PeerInfo.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PeerInfo proto.InternalMessageInfo
+
+func (m *PeerInfo) GetAddress() string {
+	if m != nil {
+		return m.Address
+	}
+	return ""
+}
+
+func (m *PeerInfo) GetIdentity() string {
+	if m != nil {
+		return m.Identity
+	}
+	return ""
+}
+
+func (m *PeerInfo) GetConnectedTime() *timestamppb.Timestamp {
+	if m != nil {
+		return m.ConnectedTime
+	}
+	return nil
+}
+
+func (m *PeerInfo) GetActiveStreams() int64 {
+	if m != nil {
+		return m.ActiveStreams
+	}
+	return 0
+}
+
+type ListPeersResponse struct {
+	// Oldest connection first.
+	Peers                []*PeerInfo `protobuf:"bytes,1,rep,name=peers,proto3" json:"peers,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
+	XXX_unrecognized     []byte      `json:"-"`
+	XXX_sizecache        int32       `json:"-"`
+}
+
+func (m *ListPeersResponse) Reset()         { *m = ListPeersResponse{} }
+func (m *ListPeersResponse) String() string { return proto.CompactTextString(m) }
+func (*ListPeersResponse) ProtoMessage()    {}
+func (*ListPeersResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_73a7fc70dcc2027c, []int{4}
+}
+
+func (m *ListPeersResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListPeersResponse.Unmarshal(m, b)
+}
+func (m *ListPeersResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListPeersResponse.Marshal(b, m, deterministic)
+}
+func (m *ListPeersResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListPeersResponse.Merge(m, src)
+}
+func (m *ListPeersResponse) XXX_Size() int {
+	return xxx_messageInfo_ListPeersResponse.Size(m)
+}
+func (m *ListPeersResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListPeersResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListPeersResponse proto.InternalMessageInfo
+
+func (m *ListPeersResponse) GetPeers() []*PeerInfo {
+	if m != nil {
+		return m.Peers
+	}
+	return nil
+}
+
+type GetConfigRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetConfigRequest) Reset()         { *m = GetConfigRequest{} }
+func (m *GetConfigRequest) String() string { return proto.CompactTextString(m) }
+func (*GetConfigRequest) ProtoMessage()    {}
+func (*GetConfigRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_73a7fc70dcc2027c, []int{5}
+}
+
+func (m *GetConfigRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetConfigRequest.Unmarshal(m, b)
+}
+func (m *GetConfigRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetConfigRequest.Marshal(b, m, deterministic)
+}
+func (m *GetConfigRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetConfigRequest.Merge(m, src)
+}
+func (m *GetConfigRequest) XXX_Size() int {
+	return xxx_messageInfo_GetConfigRequest.Size(m)
+}
+func (m *GetConfigRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetConfigRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetConfigRequest proto.InternalMessageInfo
+
+type GetConfigResponse struct {
+	// The effective configuration as YAML, in the layout of config.yaml,
+	// with the admin token redacted.
+	Yaml                 string   `protobuf:"bytes,1,opt,name=yaml,proto3" json:"yaml,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetConfigResponse) Reset()         { *m = GetConfigResponse{} }
+func (m *GetConfigResponse) String() string { return proto.CompactTextString(m) }
+func (*GetConfigResponse) ProtoMessage()    {}
+func (*GetConfigResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_73a7fc70dcc2027c, []int{6}
+}
+
+func (m *GetConfigResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetConfigResponse.Unmarshal(m, b)
+}
+func (m *GetConfigResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetConfigResponse.Marshal(b, m, deterministic)
+}
+func (m *GetConfigResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetConfigResponse.Merge(m, src)
+}
+func (m *GetConfigResponse) XXX_Size() int {
+	return xxx_messageInfo_GetConfigResponse.Size(m)
+}
+func (m *GetConfigResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetConfigResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetConfigResponse proto.InternalMessageInfo
+
+func (m *GetConfigResponse) GetYaml() string {
+	if m != nil {
+		return m.Yaml
+	}
+	return ""
+}
+
+type SetLogLevelRequest struct {
+	Level                LogLevel `protobuf:"varint,1,opt,name=level,proto3,enum=greet.LogLevel" json:"level,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SetLogLevelRequest) Reset()         { *m = SetLogLevelRequest{} }
+func (m *SetLogLevelRequest) String() string { return proto.CompactTextString(m) }
+func (*SetLogLevelRequest) ProtoMessage()    {}
+func (*SetLogLevelRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_73a7fc70dcc2027c, []int{7}
+}
+
+func (m *SetLogLevelRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SetLogLevelRequest.Unmarshal(m, b)
+}
+func (m *SetLogLevelRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SetLogLevelRequest.Marshal(b, m, deterministic)
+}
+func (m *SetLogLevelRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SetLogLevelRequest.Merge(m, src)
+}
+func (m *SetLogLevelRequest) XXX_Size() int {
+	return xxx_messageInfo_SetLogLevelRequest.Size(m)
+}
+func (m *SetLogLevelRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_SetLogLevelRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SetLogLevelRequest proto.InternalMessageInfo
+
+func (m *SetLogLevelRequest) GetLevel() LogLevel {
+	if m != nil {
+		return m.Level
+	}
+	return LogLevel_LOG_LEVEL_UNSPECIFIED
+}
+
+type SetLogLevelResponse struct {
+	PreviousLevel        LogLevel `protobuf:"varint,1,opt,name=previous_level,json=previousLevel,proto3,enum=greet.LogLevel" json:"previous_level,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SetLogLevelResponse) Reset()         { *m = SetLogLevelResponse{} }
+func (m *SetLogLevelResponse) String() string { return proto.CompactTextString(m) }
+func (*SetLogLevelResponse) ProtoMessage()    {}
+func (*SetLogLevelResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_73a7fc70dcc2027c, []int{8}
+}
+
+func (m *SetLogLevelResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SetLogLevelResponse.Unmarshal(m, b)
+}
+func (m *SetLogLevelResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SetLogLevelResponse.Marshal(b, m, deterministic)
+}
+func (m *SetLogLevelResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SetLogLevelResponse.Merge(m, src)
+}
+func (m *SetLogLevelResponse) XXX_Size() int {
+	return xxx_messageInfo_SetLogLevelResponse.Size(m)
+}
+func (m *SetLogLevelResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_SetLogLevelResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SetLogLevelResponse proto.InternalMessageInfo
+
+func (m *SetLogLevelResponse) GetPreviousLevel() LogLevel {
+	if m != nil {
+		return m.PreviousLevel
+	}
+	return LogLevel_LOG_LEVEL_UNSPECIFIED
+}
+
+func init() {
+	proto.RegisterEnum("greet.LogLevel", LogLevel_name, LogLevel_value)
+	proto.RegisterType((*GetServerStatusRequest)(nil), "greet.GetServerStatusRequest")
+	proto.RegisterType((*ServerStatus)(nil), "greet.ServerStatus")
+	proto.RegisterMapType((map[string]int64)(nil), "greet.ServerStatus.ActiveStreamsEntry")
+	proto.RegisterType((*ListPeersRequest)(nil), "greet.ListPeersRequest")
+	proto.RegisterType((*PeerInfo)(nil), "greet.PeerInfo")
+	proto.RegisterType((*ListPeersResponse)(nil), "greet.ListPeersResponse")
+	proto.RegisterType((*GetConfigRequest)(nil), "greet.GetConfigRequest")
+	proto.RegisterType((*GetConfigResponse)(nil), "greet.GetConfigResponse")
+	proto.RegisterType((*SetLogLevelRequest)(nil), "greet.SetLogLevelRequest")
+	proto.RegisterType((*SetLogLevelResponse)(nil), "greet.SetLogLevelResponse")
+}
+
+func init() { proto.RegisterFile("admin.proto", fileDescriptor_73a7fc70dcc2027c) }
+
+var fileDescriptor_73a7fc70dcc2027c = []byte{
+	// 628 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x54, 0xdb, 0x6e, 0xd3, 0x40,
+	0x10, 0xad, 0x93, 0xa6, 0x4d, 0x26, 0x34, 0x4d, 0xb7, 0x5c, 0x5c, 0x4b, 0x85, 0xc8, 0x12, 0x10,
+	0x21, 0xe4, 0x8a, 0x20, 0x21, 0x28, 0x2f, 0xbd, 0xb9, 0x51, 0xa4, 0xf4, 0x22, 0x87, 0xf6, 0x81,
+	0x17, 0xcb, 0x4d, 0xa6, 0x96, 0x85, 0xe3, 0x35, 0xde, 0x75, 0xa4, 0x7c, 0x0e, 0xaf, 0x7c, 0x1f,
+	0x1f, 0x80, 0xbc, 0xde, 0x75, 0xdd, 0x24, 0x42, 0xbc, 0xed, 0xcc, 0x99, 0x39, 0x3b, 0x73, 0x8e,
+	0xd7, 0xd0, 0xf4, 0x26, 0xd3, 0x20, 0xb2, 0xe2, 0x84, 0x72, 0x4a, 0x6a, 0x7e, 0x82, 0xc8, 0x8d,
+	0x97, 0x3e, 0xa5, 0x7e, 0x88, 0x07, 0x22, 0x79, 0x97, 0xde, 0x1f, 0x4c, 0xd2, 0xc4, 0xe3, 0x01,
+	0x95, 0x65, 0xc6, 0xab, 0x45, 0x9c, 0x07, 0x53, 0x64, 0xdc, 0x9b, 0xc6, 0x79, 0x81, 0xa9, 0xc3,
+	0xf3, 0x3e, 0xf2, 0x11, 0x26, 0x33, 0x4c, 0x46, 0xdc, 0xe3, 0x29, 0x73, 0xf0, 0x67, 0x8a, 0x8c,
+	0x9b, 0x7f, 0x2a, 0xf0, 0xa4, 0x9c, 0x27, 0x3a, 0x6c, 0xce, 0x30, 0x61, 0x01, 0x8d, 0x74, 0xad,
+	0xa3, 0x75, 0x1b, 0x8e, 0x0a, 0xc9, 0x3e, 0x80, 0x4f, 0x5d, 0x05, 0x56, 0x04, 0xd8, 0xf0, 0xe9,
+	0xad, 0x84, 0xbf, 0x00, 0x30, 0xee, 0x25, 0xdc, 0xcd, 0x2e, 0xd7, 0xab, 0x1d, 0xad, 0xdb, 0xec,
+	0x19, 0x56, 0x3e, 0x99, 0xa5, 0x26, 0xb3, 0xbe, 0xa9, 0xc9, 0x9c, 0x86, 0xa8, 0xce, 0x62, 0xf2,
+	0x01, 0x36, 0xd2, 0x58, 0xb4, 0xad, 0x8b, 0xb6, 0xbd, 0xa5, 0xb6, 0x33, 0xb9, 0xb0, 0x23, 0x0b,
+	0xc9, 0x05, 0xb4, 0xbc, 0x31, 0x0f, 0x66, 0xe8, 0x32, 0x9e, 0xa0, 0x37, 0x65, 0x7a, 0xad, 0x53,
+	0xed, 0x36, 0x7b, 0x6f, 0x2c, 0x21, 0x99, 0x55, 0xde, 0xc9, 0x3a, 0x16, 0x95, 0xa3, 0xbc, 0xd0,
+	0x8e, 0x78, 0x32, 0x77, 0xb6, 0xbc, 0x72, 0x8e, 0xbc, 0x87, 0x46, 0x48, 0x7d, 0x37, 0xc4, 0x19,
+	0x86, 0xfa, 0x46, 0x47, 0xeb, 0xb6, 0x7a, 0xdb, 0x92, 0x69, 0x48, 0xfd, 0x61, 0x96, 0x76, 0xea,
+	0xa1, 0x3c, 0x19, 0x47, 0x40, 0x96, 0x29, 0x49, 0x1b, 0xaa, 0x3f, 0x70, 0x2e, 0x55, 0xcb, 0x8e,
+	0xe4, 0x29, 0xd4, 0x66, 0x5e, 0x98, 0xa2, 0x10, 0xab, 0xea, 0xe4, 0xc1, 0x61, 0xe5, 0xb3, 0x66,
+	0x12, 0x68, 0x0f, 0x03, 0xc6, 0xaf, 0x11, 0x93, 0xc2, 0x8a, 0xdf, 0x1a, 0xd4, 0xb3, 0xc4, 0x20,
+	0xba, 0xa7, 0x99, 0x0d, 0xde, 0x64, 0x92, 0x20, 0x63, 0xca, 0x06, 0x19, 0x12, 0x03, 0xea, 0xc1,
+	0x04, 0x23, 0x1e, 0xf0, 0xb9, 0x34, 0xa1, 0x88, 0xc9, 0x31, 0xb4, 0xc6, 0x34, 0x8a, 0x70, 0xcc,
+	0x71, 0xf2, 0xbf, 0x3e, 0x6c, 0x15, 0x1d, 0xc2, 0x8b, 0xd7, 0x4b, 0xc2, 0xae, 0x8b, 0xe1, 0x1f,
+	0x0b, 0x66, 0x1e, 0xc2, 0x4e, 0x69, 0x01, 0x16, 0xd3, 0x88, 0x65, 0xbd, 0xb5, 0x38, 0x4b, 0xe8,
+	0x9a, 0xf0, 0x42, 0x29, 0xa8, 0x96, 0x72, 0x72, 0x34, 0x5b, 0xbe, 0x8f, 0xfc, 0x94, 0x46, 0xf7,
+	0x81, 0xaf, 0x96, 0x7f, 0x0b, 0x3b, 0xa5, 0x9c, 0xe4, 0x23, 0xb0, 0x3e, 0xf7, 0xa6, 0xa1, 0x54,
+	0x40, 0x9c, 0xcd, 0xaf, 0x40, 0x46, 0xc8, 0x0b, 0x53, 0xf2, 0xf6, 0xec, 0xe6, 0xdc, 0x3b, 0x6d,
+	0xb5, 0x77, 0x39, 0x6a, 0x5e, 0xc0, 0xee, 0xa3, 0x66, 0x79, 0xcf, 0x27, 0x68, 0xc5, 0x09, 0xce,
+	0x02, 0x9a, 0x32, 0xf7, 0x9f, 0x34, 0x5b, 0xaa, 0x4c, 0x84, 0xef, 0xc6, 0x50, 0x57, 0x10, 0xd9,
+	0x83, 0x67, 0xc3, 0xab, 0xbe, 0x3b, 0xb4, 0x6f, 0xed, 0xa1, 0x7b, 0x73, 0x39, 0xba, 0xb6, 0x4f,
+	0x07, 0xe7, 0x03, 0xfb, 0xac, 0xbd, 0x46, 0x76, 0x61, 0xfb, 0x01, 0x3a, 0xb3, 0x4f, 0x6e, 0xfa,
+	0x6d, 0x8d, 0x10, 0x68, 0x3d, 0x24, 0x07, 0x97, 0xe7, 0x57, 0xed, 0xca, 0xe3, 0x42, 0xdb, 0x71,
+	0xae, 0x9c, 0x76, 0xb5, 0xf7, 0xab, 0x02, 0xd0, 0xcf, 0xc6, 0x38, 0xce, 0x7e, 0x0c, 0xa4, 0x0f,
+	0xdb, 0x0b, 0x4f, 0x99, 0xec, 0xcb, 0x31, 0x57, 0x3f, 0x71, 0x63, 0x77, 0xc5, 0x93, 0x30, 0xd7,
+	0xc8, 0x11, 0x34, 0x0a, 0x07, 0xc9, 0x0b, 0xb5, 0xe9, 0xc2, 0x47, 0x69, 0xe8, 0xcb, 0x40, 0x2e,
+	0x5a, 0xce, 0x50, 0x78, 0x56, 0x30, 0x2c, 0x3a, 0x6b, 0xe8, 0xcb, 0x40, 0xc1, 0x70, 0x0e, 0xcd,
+	0x92, 0x1f, 0x64, 0xaf, 0x98, 0x74, 0xd1, 0x60, 0xc3, 0x58, 0x05, 0x29, 0x9e, 0x93, 0xc6, 0xf7,
+	0x4d, 0x01, 0xc7, 0x77, 0x77, 0x1b, 0xe2, 0x13, 0xff, 0xf8, 0x77, 0x00, 0x95, 0xd4, 0xf7, 0xc5,
+	0x48, 0x05, 0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// GreetAdminClient is the client API for GreetAdmin service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type GreetAdminClient interface {
+	GetServerStatus(ctx context.Context, in *GetServerStatusRequest, opts ...grpc.CallOption) (*ServerStatus, error)
+	ListPeers(ctx context.Context, in *ListPeersRequest, opts ...grpc.CallOption) (*ListPeersResponse, error)
+	GetConfig(ctx context.Context, in *GetConfigRequest, opts ...grpc.CallOption) (*GetConfigResponse, error)
+	SetLogLevel(ctx context.Context, in *SetLogLevelRequest, opts ...grpc.CallOption) (*SetLogLevelResponse, error)
+}
+
+type greetAdminClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewGreetAdminClient(cc *grpc.ClientConn) GreetAdminClient {
+	return &greetAdminClient{cc}
+}
+
+func (c *greetAdminClient) GetServerStatus(ctx context.Context, in *GetServerStatusRequest, opts ...grpc.CallOption) (*ServerStatus, error) {
+	out := new(ServerStatus)
+	err := c.cc.Invoke(ctx, "/greet.GreetAdmin/GetServerStatus", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *greetAdminClient) ListPeers(ctx context.Context, in *ListPeersRequest, opts ...grpc.CallOption) (*ListPeersResponse, error) {
+	out := new(ListPeersResponse)
+	err := c.cc.Invoke(ctx, "/greet.GreetAdmin/ListPeers", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *greetAdminClient) GetConfig(ctx context.Context, in *GetConfigRequest, opts ...grpc.CallOption) (*GetConfigResponse, error) {
+	out := new(GetConfigResponse)
+	err := c.cc.Invoke(ctx, "/greet.GreetAdmin/GetConfig", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *greetAdminClient) SetLogLevel(ctx context.Context, in *SetLogLevelRequest, opts ...grpc.CallOption) (*SetLogLevelResponse, error) {
+	out := new(SetLogLevelResponse)
+	err := c.cc.Invoke(ctx, "/greet.GreetAdmin/SetLogLevel", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GreetAdminServer is the server API for GreetAdmin service.
+type GreetAdminServer interface {
+	GetServerStatus(context.Context, *GetServerStatusRequest) (*ServerStatus, error)
+	ListPeers(context.Context, *ListPeersRequest) (*ListPeersResponse, error)
+	GetConfig(context.Context, *GetConfigRequest) (*GetConfigResponse, error)
+	SetLogLevel(context.Context, *SetLogLevelRequest) (*SetLogLevelResponse, error)
+}
+
+// UnimplementedGreetAdminServer can be embedded to have forward compatible implementations.
+type UnimplementedGreetAdminServer struct {
+}
+
+func (*UnimplementedGreetAdminServer) GetServerStatus(ctx context.Context, req *GetServerStatusRequest) (*ServerStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetServerStatus not implemented")
+}
+func (*UnimplementedGreetAdminServer) ListPeers(ctx context.Context, req *ListPeersRequest) (*ListPeersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListPeers not implemented")
+}
+func (*UnimplementedGreetAdminServer) GetConfig(ctx context.Context, req *GetConfigRequest) (*GetConfigResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetConfig not implemented")
+}
+func (*UnimplementedGreetAdminServer) SetLogLevel(ctx context.Context, req *SetLogLevelRequest) (*SetLogLevelResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetLogLevel not implemented")
+}
+
+func RegisterGreetAdminServer(s *grpc.Server, srv GreetAdminServer) {
+	s.RegisterService(&_GreetAdmin_serviceDesc, srv)
+}
+
+func _GreetAdmin_GetServerStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetServerStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GreetAdminServer).GetServerStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/greet.GreetAdmin/GetServerStatus",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GreetAdminServer).GetServerStatus(ctx, req.(*GetServerStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GreetAdmin_ListPeers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListPeersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GreetAdminServer).ListPeers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/greet.GreetAdmin/ListPeers",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GreetAdminServer).ListPeers(ctx, req.(*ListPeersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GreetAdmin_GetConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetConfigRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GreetAdminServer).GetConfig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/greet.GreetAdmin/GetConfig",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GreetAdminServer).GetConfig(ctx, req.(*GetConfigRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GreetAdmin_SetLogLevel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetLogLevelRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GreetAdminServer).SetLogLevel(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/greet.GreetAdmin/SetLogLevel",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GreetAdminServer).SetLogLevel(ctx, req.(*SetLogLevelRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _GreetAdmin_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "greet.GreetAdmin",
+	HandlerType: (*GreetAdminServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetServerStatus",
+			Handler:    _GreetAdmin_GetServerStatus_Handler,
+		},
+		{
+			MethodName: "ListPeers",
+			Handler:    _GreetAdmin_ListPeers_Handler,
+		},
+		{
+			MethodName: "GetConfig",
+			Handler:    _GreetAdmin_GetConfig_Handler,
+		},
+		{
+			MethodName: "SetLogLevel",
+			Handler:    _GreetAdmin_SetLogLevel_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "admin.proto",
+}
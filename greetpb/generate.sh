@@ -1,7 +1,13 @@
 #!/bin/bash
 
 ## Greetings
-protoc ./greet.proto --go_out=plugins=grpc:.
+protoc -I . -I ../third_party/googleapis ./greet.proto \
+    --go_out=plugins=grpc:. \
+    --grpc-gateway_out=logtostderr=true:. \
+    --swagger_out=logtostderr=true:.
+
+## Admin
+protoc -I . ./admin.proto --go_out=plugins=grpc:.
 
 ## Calculator
-#protoc greet/calculatorpb/calculator.proto --go_out=plugins=grpc:.
\ No newline at end of file
+#protoc greet/calculatorpb/calculator.proto --go_out=plugins=grpc:.
@@ -7,9 +7,11 @@ import (
 	context "context"
 	fmt "fmt"
 	proto "github.com/golang/protobuf/proto"
+	_ "google.golang.org/genproto/googleapis/api/annotations"
 	grpc "google.golang.org/grpc"
 	codes "google.golang.org/grpc/codes"
 	status "google.golang.org/grpc/status"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 	math "math"
 )
 
@@ -24,6 +26,73 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
 
+// Presence marks GreetEveryOne responses that announce a room member.
+type Presence int32
+
+const (
+	Presence_PRESENCE_UNSPECIFIED Presence = 0
+	Presence_PRESENCE_JOINED      Presence = 1
+	Presence_PRESENCE_LEFT        Presence = 2
+)
+
+var Presence_name = map[int32]string{
+	0: "PRESENCE_UNSPECIFIED",
+	1: "PRESENCE_JOINED",
+	2: "PRESENCE_LEFT",
+}
+
+var Presence_value = map[string]int32{
+	"PRESENCE_UNSPECIFIED": 0,
+	"PRESENCE_JOINED":      1,
+	"PRESENCE_LEFT":        2,
+}
+
+func (x Presence) String() string {
+	return proto.EnumName(Presence_name, int32(x))
+}
+
+func (Presence) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_32c0044392f32579, []int{0}
+}
+
+// RpcType names the GreetService method that handled a greeting.
+type RpcType int32
+
+const (
+	RpcType_RPC_TYPE_UNSPECIFIED      RpcType = 0
+	RpcType_RPC_TYPE_GREET            RpcType = 1
+	RpcType_RPC_TYPE_LONG_GREET       RpcType = 2
+	RpcType_RPC_TYPE_GREET_EVERY_ONE  RpcType = 3
+	RpcType_RPC_TYPE_GREET_MANY_TIMES RpcType = 4
+	RpcType_RPC_TYPE_GREET_BATCH      RpcType = 5
+)
+
+var RpcType_name = map[int32]string{
+	0: "RPC_TYPE_UNSPECIFIED",
+	1: "RPC_TYPE_GREET",
+	2: "RPC_TYPE_LONG_GREET",
+	3: "RPC_TYPE_GREET_EVERY_ONE",
+	4: "RPC_TYPE_GREET_MANY_TIMES",
+	5: "RPC_TYPE_GREET_BATCH",
+}
+
+var RpcType_value = map[string]int32{
+	"RPC_TYPE_UNSPECIFIED":      0,
+	"RPC_TYPE_GREET":            1,
+	"RPC_TYPE_LONG_GREET":       2,
+	"RPC_TYPE_GREET_EVERY_ONE":  3,
+	"RPC_TYPE_GREET_MANY_TIMES": 4,
+	"RPC_TYPE_GREET_BATCH":      5,
+}
+
+func (x RpcType) String() string {
+	return proto.EnumName(RpcType_name, int32(x))
+}
+
+func (RpcType) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_32c0044392f32579, []int{1}
+}
+
 type Greeting struct {
 	FirstName            string   `protobuf:"bytes,1,opt,name=first_name,json=firstName,proto3" json:"first_name,omitempty"`
 	LastName             string   `protobuf:"bytes,2,opt,name=last_name,json=lastName,proto3" json:"last_name,omitempty"`
@@ -72,10 +141,19 @@ func (m *Greeting) GetLastName() string {
 }
 
 type GreetRequest struct {
-	Greeting             *Greeting `protobuf:"bytes,1,opt,name=greeting,proto3" json:"greeting,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
-	XXX_unrecognized     []byte    `json:"-"`
-	XXX_sizecache        int32     `json:"-"`
+	Greeting *Greeting `protobuf:"bytes,1,opt,name=greeting,proto3" json:"greeting,omitempty"`
+	// Set by GreetEveryOne clients, increasing by one per message, so that
+	// requests can be acknowledged and resent after a reconnect.
+	SequenceId uint64 `protobuf:"varint,2,opt,name=sequence_id,json=sequenceId,proto3" json:"sequence_id,omitempty"`
+	// A resume_token from an interrupted GreetManyTimes stream; the server
+	// continues with the message after the one that carried it.
+	ResumeToken string `protobuf:"bytes,3,opt,name=resume_token,json=resumeToken,proto3" json:"resume_token,omitempty"`
+	// GreetEveryOne clients that set a room also receive the greetings of
+	// everybody else in it, and are announced when they join and leave.
+	Room                 string   `protobuf:"bytes,4,opt,name=room,proto3" json:"room,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
 func (m *GreetRequest) Reset()         { *m = GreetRequest{} }
@@ -110,8 +188,43 @@ func (m *GreetRequest) GetGreeting() *Greeting {
 	return nil
 }
 
+func (m *GreetRequest) GetSequenceId() uint64 {
+	if m != nil {
+		return m.SequenceId
+	}
+	return 0
+}
+
+func (m *GreetRequest) GetResumeToken() string {
+	if m != nil {
+		return m.ResumeToken
+	}
+	return ""
+}
+
+func (m *GreetRequest) GetRoom() string {
+	if m != nil {
+		return m.Room
+	}
+	return ""
+}
+
 type GreetResponse struct {
-	Result               string   `protobuf:"bytes,1,opt,name=result,proto3" json:"result,omitempty"`
+	Result string `protobuf:"bytes,1,opt,name=result,proto3" json:"result,omitempty"`
+	// The sequence_id of the GreetEveryOne request this response answers.
+	// Every request up to and including it has been processed.
+	AckSequenceId uint64 `protobuf:"varint,2,opt,name=ack_sequence_id,json=ackSequenceId,proto3" json:"ack_sequence_id,omitempty"`
+	// Opaque position of this message in a GreetManyTimes stream.
+	ResumeToken string `protobuf:"bytes,3,opt,name=resume_token,json=resumeToken,proto3" json:"resume_token,omitempty"`
+	// For greetings and presence events relayed from another member of a
+	// GreetEveryOne room: the room and who it came from.
+	Room     string    `protobuf:"bytes,4,opt,name=room,proto3" json:"room,omitempty"`
+	From     *Greeting `protobuf:"bytes,5,opt,name=from,proto3" json:"from,omitempty"`
+	Presence Presence  `protobuf:"varint,6,opt,name=presence,proto3,enum=greet.Presence" json:"presence,omitempty"`
+	// How many times Greet has greeted this person, this call included.
+	// People are told apart by first and last name, ignoring case and
+	// extra spaces.
+	GreetCount           uint64   `protobuf:"varint,7,opt,name=greet_count,json=greetCount,proto3" json:"greet_count,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -149,73 +262,666 @@ func (m *GreetResponse) GetResult() string {
 	return ""
 }
 
-type Numbers struct {
-	Number               int32    `protobuf:"varint,1,opt,name=number,proto3" json:"number,omitempty"`
+func (m *GreetResponse) GetAckSequenceId() uint64 {
+	if m != nil {
+		return m.AckSequenceId
+	}
+	return 0
+}
+
+func (m *GreetResponse) GetResumeToken() string {
+	if m != nil {
+		return m.ResumeToken
+	}
+	return ""
+}
+
+func (m *GreetResponse) GetRoom() string {
+	if m != nil {
+		return m.Room
+	}
+	return ""
+}
+
+func (m *GreetResponse) GetFrom() *Greeting {
+	if m != nil {
+		return m.From
+	}
+	return nil
+}
+
+func (m *GreetResponse) GetPresence() Presence {
+	if m != nil {
+		return m.Presence
+	}
+	return Presence_PRESENCE_UNSPECIFIED
+}
+
+func (m *GreetResponse) GetGreetCount() uint64 {
+	if m != nil {
+		return m.GreetCount
+	}
+	return 0
+}
+
+type ResetGreetingCountRequest struct {
+	Greeting             *Greeting `protobuf:"bytes,1,opt,name=greeting,proto3" json:"greeting,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
+}
+
+func (m *ResetGreetingCountRequest) Reset()         { *m = ResetGreetingCountRequest{} }
+func (m *ResetGreetingCountRequest) String() string { return proto.CompactTextString(m) }
+func (*ResetGreetingCountRequest) ProtoMessage()    {}
+func (*ResetGreetingCountRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_32c0044392f32579, []int{3}
+}
+
+func (m *ResetGreetingCountRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ResetGreetingCountRequest.Unmarshal(m, b)
+}
+func (m *ResetGreetingCountRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ResetGreetingCountRequest.Marshal(b, m, deterministic)
+}
+func (m *ResetGreetingCountRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ResetGreetingCountRequest.Merge(m, src)
+}
+func (m *ResetGreetingCountRequest) XXX_Size() int {
+	return xxx_messageInfo_ResetGreetingCountRequest.Size(m)
+}
+func (m *ResetGreetingCountRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ResetGreetingCountRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ResetGreetingCountRequest proto.InternalMessageInfo
+
+func (m *ResetGreetingCountRequest) GetGreeting() *Greeting {
+	if m != nil {
+		return m.Greeting
+	}
+	return nil
+}
+
+type ResetGreetingCountResponse struct {
+	PreviousCount        uint64   `protobuf:"varint,1,opt,name=previous_count,json=previousCount,proto3" json:"previous_count,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *Numbers) Reset()         { *m = Numbers{} }
-func (m *Numbers) String() string { return proto.CompactTextString(m) }
-func (*Numbers) ProtoMessage()    {}
-func (*Numbers) Descriptor() ([]byte, []int) {
-	return fileDescriptor_32c0044392f32579, []int{3}
+func (m *ResetGreetingCountResponse) Reset()         { *m = ResetGreetingCountResponse{} }
+func (m *ResetGreetingCountResponse) String() string { return proto.CompactTextString(m) }
+func (*ResetGreetingCountResponse) ProtoMessage()    {}
+func (*ResetGreetingCountResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_32c0044392f32579, []int{4}
 }
 
-func (m *Numbers) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_Numbers.Unmarshal(m, b)
+func (m *ResetGreetingCountResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ResetGreetingCountResponse.Unmarshal(m, b)
 }
-func (m *Numbers) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_Numbers.Marshal(b, m, deterministic)
+func (m *ResetGreetingCountResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ResetGreetingCountResponse.Marshal(b, m, deterministic)
 }
-func (m *Numbers) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_Numbers.Merge(m, src)
+func (m *ResetGreetingCountResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ResetGreetingCountResponse.Merge(m, src)
 }
-func (m *Numbers) XXX_Size() int {
-	return xxx_messageInfo_Numbers.Size(m)
+func (m *ResetGreetingCountResponse) XXX_Size() int {
+	return xxx_messageInfo_ResetGreetingCountResponse.Size(m)
 }
-func (m *Numbers) XXX_DiscardUnknown() {
-	xxx_messageInfo_Numbers.DiscardUnknown(m)
+func (m *ResetGreetingCountResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ResetGreetingCountResponse.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_Numbers proto.InternalMessageInfo
+var xxx_messageInfo_ResetGreetingCountResponse proto.InternalMessageInfo
 
-func (m *Numbers) GetNumber() int32 {
+func (m *ResetGreetingCountResponse) GetPreviousCount() uint64 {
 	if m != nil {
-		return m.Number
+		return m.PreviousCount
 	}
 	return 0
 }
 
+type SubscribeGreetingsRequest struct {
+	// Only greetings whose names start with these prefixes are sent; an
+	// empty prefix matches every name.
+	FirstNamePrefix      string   `protobuf:"bytes,1,opt,name=first_name_prefix,json=firstNamePrefix,proto3" json:"first_name_prefix,omitempty"`
+	LastNamePrefix       string   `protobuf:"bytes,2,opt,name=last_name_prefix,json=lastNamePrefix,proto3" json:"last_name_prefix,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SubscribeGreetingsRequest) Reset()         { *m = SubscribeGreetingsRequest{} }
+func (m *SubscribeGreetingsRequest) String() string { return proto.CompactTextString(m) }
+func (*SubscribeGreetingsRequest) ProtoMessage()    {}
+func (*SubscribeGreetingsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_32c0044392f32579, []int{5}
+}
+
+func (m *SubscribeGreetingsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SubscribeGreetingsRequest.Unmarshal(m, b)
+}
+func (m *SubscribeGreetingsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SubscribeGreetingsRequest.Marshal(b, m, deterministic)
+}
+func (m *SubscribeGreetingsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SubscribeGreetingsRequest.Merge(m, src)
+}
+func (m *SubscribeGreetingsRequest) XXX_Size() int {
+	return xxx_messageInfo_SubscribeGreetingsRequest.Size(m)
+}
+func (m *SubscribeGreetingsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_SubscribeGreetingsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SubscribeGreetingsRequest proto.InternalMessageInfo
+
+func (m *SubscribeGreetingsRequest) GetFirstNamePrefix() string {
+	if m != nil {
+		return m.FirstNamePrefix
+	}
+	return ""
+}
+
+func (m *SubscribeGreetingsRequest) GetLastNamePrefix() string {
+	if m != nil {
+		return m.LastNamePrefix
+	}
+	return ""
+}
+
+// GreetingEvent describes a greeting handled by the server. A LongGreet
+// event is sent when the call completes and lists every greeting in it.
+type GreetingEvent struct {
+	Rpc       RpcType                `protobuf:"varint,1,opt,name=rpc,proto3,enum=greet.RpcType" json:"rpc,omitempty"`
+	Greetings []*Greeting            `protobuf:"bytes,2,rep,name=greetings,proto3" json:"greetings,omitempty"`
+	Result    string                 `protobuf:"bytes,3,opt,name=result,proto3" json:"result,omitempty"`
+	Time      *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=time,proto3" json:"time,omitempty"`
+	// Address of the client that sent the greeting.
+	Peer                 string   `protobuf:"bytes,5,opt,name=peer,proto3" json:"peer,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GreetingEvent) Reset()         { *m = GreetingEvent{} }
+func (m *GreetingEvent) String() string { return proto.CompactTextString(m) }
+func (*GreetingEvent) ProtoMessage()    {}
+func (*GreetingEvent) Descriptor() ([]byte, []int) {
+	return fileDescriptor_32c0044392f32579, []int{6}
+}
+
+func (m *GreetingEvent) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GreetingEvent.Unmarshal(m, b)
+}
+func (m *GreetingEvent) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GreetingEvent.Marshal(b, m, deterministic)
+}
+func (m *GreetingEvent) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GreetingEvent.Merge(m, src)
+}
+func (m *GreetingEvent) XXX_Size() int {
+	return xxx_messageInfo_GreetingEvent.Size(m)
+}
+func (m *GreetingEvent) XXX_DiscardUnknown() {
+	xxx_messageInfo_GreetingEvent.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GreetingEvent proto.InternalMessageInfo
+
+func (m *GreetingEvent) GetRpc() RpcType {
+	if m != nil {
+		return m.Rpc
+	}
+	return RpcType_RPC_TYPE_UNSPECIFIED
+}
+
+func (m *GreetingEvent) GetGreetings() []*Greeting {
+	if m != nil {
+		return m.Greetings
+	}
+	return nil
+}
+
+func (m *GreetingEvent) GetResult() string {
+	if m != nil {
+		return m.Result
+	}
+	return ""
+}
+
+func (m *GreetingEvent) GetTime() *timestamppb.Timestamp {
+	if m != nil {
+		return m.Time
+	}
+	return nil
+}
+
+func (m *GreetingEvent) GetPeer() string {
+	if m != nil {
+		return m.Peer
+	}
+	return ""
+}
+
+// GreetingRecord is a greeting kept in the server's history.
+type GreetingRecord struct {
+	Greeting *Greeting              `protobuf:"bytes,1,opt,name=greeting,proto3" json:"greeting,omitempty"`
+	Rpc      RpcType                `protobuf:"varint,2,opt,name=rpc,proto3,enum=greet.RpcType" json:"rpc,omitempty"`
+	Time     *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=time,proto3" json:"time,omitempty"`
+	// The client that sent the greeting: the common name of its TLS
+	// certificate when it presented one, otherwise its address.
+	Caller               string   `protobuf:"bytes,4,opt,name=caller,proto3" json:"caller,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GreetingRecord) Reset()         { *m = GreetingRecord{} }
+func (m *GreetingRecord) String() string { return proto.CompactTextString(m) }
+func (*GreetingRecord) ProtoMessage()    {}
+func (*GreetingRecord) Descriptor() ([]byte, []int) {
+	return fileDescriptor_32c0044392f32579, []int{7}
+}
+
+func (m *GreetingRecord) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GreetingRecord.Unmarshal(m, b)
+}
+func (m *GreetingRecord) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GreetingRecord.Marshal(b, m, deterministic)
+}
+func (m *GreetingRecord) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GreetingRecord.Merge(m, src)
+}
+func (m *GreetingRecord) XXX_Size() int {
+	return xxx_messageInfo_GreetingRecord.Size(m)
+}
+func (m *GreetingRecord) XXX_DiscardUnknown() {
+	xxx_messageInfo_GreetingRecord.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GreetingRecord proto.InternalMessageInfo
+
+func (m *GreetingRecord) GetGreeting() *Greeting {
+	if m != nil {
+		return m.Greeting
+	}
+	return nil
+}
+
+func (m *GreetingRecord) GetRpc() RpcType {
+	if m != nil {
+		return m.Rpc
+	}
+	return RpcType_RPC_TYPE_UNSPECIFIED
+}
+
+func (m *GreetingRecord) GetTime() *timestamppb.Timestamp {
+	if m != nil {
+		return m.Time
+	}
+	return nil
+}
+
+func (m *GreetingRecord) GetCaller() string {
+	if m != nil {
+		return m.Caller
+	}
+	return ""
+}
+
+type ListGreetingsRequest struct {
+	// At most page_size records are returned, 50 when unset, up to 1000.
+	PageSize int32 `protobuf:"varint,1,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// next_page_token of the previous page.
+	PageToken string `protobuf:"bytes,2,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	// Only greetings from start_time (inclusive) to end_time (exclusive);
+	// either can be left out.
+	StartTime            *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=start_time,json=startTime,proto3" json:"start_time,omitempty"`
+	EndTime              *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=end_time,json=endTime,proto3" json:"end_time,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}               `json:"-"`
+	XXX_unrecognized     []byte                 `json:"-"`
+	XXX_sizecache        int32                  `json:"-"`
+}
+
+func (m *ListGreetingsRequest) Reset()         { *m = ListGreetingsRequest{} }
+func (m *ListGreetingsRequest) String() string { return proto.CompactTextString(m) }
+func (*ListGreetingsRequest) ProtoMessage()    {}
+func (*ListGreetingsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_32c0044392f32579, []int{8}
+}
+
+func (m *ListGreetingsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListGreetingsRequest.Unmarshal(m, b)
+}
+func (m *ListGreetingsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListGreetingsRequest.Marshal(b, m, deterministic)
+}
+func (m *ListGreetingsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListGreetingsRequest.Merge(m, src)
+}
+func (m *ListGreetingsRequest) XXX_Size() int {
+	return xxx_messageInfo_ListGreetingsRequest.Size(m)
+}
+func (m *ListGreetingsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListGreetingsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListGreetingsRequest proto.InternalMessageInfo
+
+func (m *ListGreetingsRequest) GetPageSize() int32 {
+	if m != nil {
+		return m.PageSize
+	}
+	return 0
+}
+
+func (m *ListGreetingsRequest) GetPageToken() string {
+	if m != nil {
+		return m.PageToken
+	}
+	return ""
+}
+
+func (m *ListGreetingsRequest) GetStartTime() *timestamppb.Timestamp {
+	if m != nil {
+		return m.StartTime
+	}
+	return nil
+}
+
+func (m *ListGreetingsRequest) GetEndTime() *timestamppb.Timestamp {
+	if m != nil {
+		return m.EndTime
+	}
+	return nil
+}
+
+type ListGreetingsResponse struct {
+	// Oldest first.
+	Greetings []*GreetingRecord `protobuf:"bytes,1,rep,name=greetings,proto3" json:"greetings,omitempty"`
+	// Empty on the last page.
+	NextPageToken        string   `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListGreetingsResponse) Reset()         { *m = ListGreetingsResponse{} }
+func (m *ListGreetingsResponse) String() string { return proto.CompactTextString(m) }
+func (*ListGreetingsResponse) ProtoMessage()    {}
+func (*ListGreetingsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_32c0044392f32579, []int{9}
+}
+
+func (m *ListGreetingsResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListGreetingsResponse.Unmarshal(m, b)
+}
+func (m *ListGreetingsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListGreetingsResponse.Marshal(b, m, deterministic)
+}
+func (m *ListGreetingsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListGreetingsResponse.Merge(m, src)
+}
+func (m *ListGreetingsResponse) XXX_Size() int {
+	return xxx_messageInfo_ListGreetingsResponse.Size(m)
+}
+func (m *ListGreetingsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListGreetingsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListGreetingsResponse proto.InternalMessageInfo
+
+func (m *ListGreetingsResponse) GetGreetings() []*GreetingRecord {
+	if m != nil {
+		return m.Greetings
+	}
+	return nil
+}
+
+func (m *ListGreetingsResponse) GetNextPageToken() string {
+	if m != nil {
+		return m.NextPageToken
+	}
+	return ""
+}
+
+type GreetBatchRequest struct {
+	// At most the server's maximum batch size, 100 by default.
+	Greetings            []*Greeting `protobuf:"bytes,1,rep,name=greetings,proto3" json:"greetings,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
+	XXX_unrecognized     []byte      `json:"-"`
+	XXX_sizecache        int32       `json:"-"`
+}
+
+func (m *GreetBatchRequest) Reset()         { *m = GreetBatchRequest{} }
+func (m *GreetBatchRequest) String() string { return proto.CompactTextString(m) }
+func (*GreetBatchRequest) ProtoMessage()    {}
+func (*GreetBatchRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_32c0044392f32579, []int{10}
+}
+
+func (m *GreetBatchRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GreetBatchRequest.Unmarshal(m, b)
+}
+func (m *GreetBatchRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GreetBatchRequest.Marshal(b, m, deterministic)
+}
+func (m *GreetBatchRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GreetBatchRequest.Merge(m, src)
+}
+func (m *GreetBatchRequest) XXX_Size() int {
+	return xxx_messageInfo_GreetBatchRequest.Size(m)
+}
+func (m *GreetBatchRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GreetBatchRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GreetBatchRequest proto.InternalMessageInfo
+
+func (m *GreetBatchRequest) GetGreetings() []*Greeting {
+	if m != nil {
+		return m.Greetings
+	}
+	return nil
+}
+
+// GreetResult is the outcome of one greeting of a batch. code uses the
+// gRPC status code numbering; result and greet_count are only set when it
+// is OK (0), otherwise message says what was wrong with the greeting.
+type GreetResult struct {
+	Code                 int32    `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`
+	Message              string   `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Result               string   `protobuf:"bytes,3,opt,name=result,proto3" json:"result,omitempty"`
+	GreetCount           uint64   `protobuf:"varint,4,opt,name=greet_count,json=greetCount,proto3" json:"greet_count,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GreetResult) Reset()         { *m = GreetResult{} }
+func (m *GreetResult) String() string { return proto.CompactTextString(m) }
+func (*GreetResult) ProtoMessage()    {}
+func (*GreetResult) Descriptor() ([]byte, []int) {
+	return fileDescriptor_32c0044392f32579, []int{11}
+}
+
+func (m *GreetResult) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GreetResult.Unmarshal(m, b)
+}
+func (m *GreetResult) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GreetResult.Marshal(b, m, deterministic)
+}
+func (m *GreetResult) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GreetResult.Merge(m, src)
+}
+func (m *GreetResult) XXX_Size() int {
+	return xxx_messageInfo_GreetResult.Size(m)
+}
+func (m *GreetResult) XXX_DiscardUnknown() {
+	xxx_messageInfo_GreetResult.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GreetResult proto.InternalMessageInfo
+
+func (m *GreetResult) GetCode() int32 {
+	if m != nil {
+		return m.Code
+	}
+	return 0
+}
+
+func (m *GreetResult) GetMessage() string {
+	if m != nil {
+		return m.Message
+	}
+	return ""
+}
+
+func (m *GreetResult) GetResult() string {
+	if m != nil {
+		return m.Result
+	}
+	return ""
+}
+
+func (m *GreetResult) GetGreetCount() uint64 {
+	if m != nil {
+		return m.GreetCount
+	}
+	return 0
+}
+
+type GreetBatchResponse struct {
+	// One per greeting of the request, in the same order.
+	Results              []*GreetResult `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
+	XXX_unrecognized     []byte         `json:"-"`
+	XXX_sizecache        int32          `json:"-"`
+}
+
+func (m *GreetBatchResponse) Reset()         { *m = GreetBatchResponse{} }
+func (m *GreetBatchResponse) String() string { return proto.CompactTextString(m) }
+func (*GreetBatchResponse) ProtoMessage()    {}
+func (*GreetBatchResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_32c0044392f32579, []int{12}
+}
+
+func (m *GreetBatchResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GreetBatchResponse.Unmarshal(m, b)
+}
+func (m *GreetBatchResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GreetBatchResponse.Marshal(b, m, deterministic)
+}
+func (m *GreetBatchResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GreetBatchResponse.Merge(m, src)
+}
+func (m *GreetBatchResponse) XXX_Size() int {
+	return xxx_messageInfo_GreetBatchResponse.Size(m)
+}
+func (m *GreetBatchResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GreetBatchResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GreetBatchResponse proto.InternalMessageInfo
+
+func (m *GreetBatchResponse) GetResults() []*GreetResult {
+	if m != nil {
+		return m.Results
+	}
+	return nil
+}
+
 func init() {
+	proto.RegisterEnum("greet.Presence", Presence_name, Presence_value)
+	proto.RegisterEnum("greet.RpcType", RpcType_name, RpcType_value)
 	proto.RegisterType((*Greeting)(nil), "greet.Greeting")
 	proto.RegisterType((*GreetRequest)(nil), "greet.GreetRequest")
 	proto.RegisterType((*GreetResponse)(nil), "greet.GreetResponse")
-	proto.RegisterType((*Numbers)(nil), "greet.Numbers")
+	proto.RegisterType((*ResetGreetingCountRequest)(nil), "greet.ResetGreetingCountRequest")
+	proto.RegisterType((*ResetGreetingCountResponse)(nil), "greet.ResetGreetingCountResponse")
+	proto.RegisterType((*SubscribeGreetingsRequest)(nil), "greet.SubscribeGreetingsRequest")
+	proto.RegisterType((*GreetingEvent)(nil), "greet.GreetingEvent")
+	proto.RegisterType((*GreetingRecord)(nil), "greet.GreetingRecord")
+	proto.RegisterType((*ListGreetingsRequest)(nil), "greet.ListGreetingsRequest")
+	proto.RegisterType((*ListGreetingsResponse)(nil), "greet.ListGreetingsResponse")
+	proto.RegisterType((*GreetBatchRequest)(nil), "greet.GreetBatchRequest")
+	proto.RegisterType((*GreetResult)(nil), "greet.GreetResult")
+	proto.RegisterType((*GreetBatchResponse)(nil), "greet.GreetBatchResponse")
 }
 
 func init() { proto.RegisterFile("greet.proto", fileDescriptor_32c0044392f32579) }
 
 var fileDescriptor_32c0044392f32579 = []byte{
-	// 266 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x51, 0x4d, 0x4b, 0xc3, 0x40,
-	0x10, 0x75, 0x0b, 0x69, 0xb3, 0x53, 0x3f, 0x60, 0x14, 0x11, 0x45, 0xd0, 0xbd, 0x58, 0x10, 0x4a,
-	0xa9, 0x9e, 0x14, 0x41, 0x04, 0xf5, 0xa2, 0x15, 0xa2, 0x27, 0x2f, 0x92, 0xc8, 0x18, 0x02, 0xcd,
-	0x26, 0xee, 0x6e, 0x0a, 0x3d, 0xfa, 0xcf, 0x65, 0xa7, 0x1b, 0xb1, 0xc7, 0xdc, 0xe6, 0xbd, 0x37,
-	0xf3, 0xde, 0x63, 0x17, 0x86, 0xb9, 0x21, 0x72, 0xe3, 0xda, 0x54, 0xae, 0xc2, 0x88, 0x81, 0x7a,
-	0x80, 0xf8, 0xd1, 0x0f, 0x85, 0xce, 0xf1, 0x18, 0xe0, 0xab, 0x30, 0xd6, 0x7d, 0xe8, 0xb4, 0xa4,
-	0x03, 0x71, 0x22, 0x46, 0x32, 0x91, 0xcc, 0xcc, 0xd2, 0x92, 0xf0, 0x08, 0xe4, 0x3c, 0x6d, 0xd5,
-	0x1e, 0xab, 0xb1, 0x27, 0xbc, 0xa8, 0xae, 0x61, 0x93, 0x7d, 0x12, 0xfa, 0x6e, 0xc8, 0x3a, 0x3c,
-	0x87, 0x38, 0x0f, 0xbe, 0xec, 0x34, 0x9c, 0xee, 0x8c, 0x57, 0xf1, 0x6d, 0x5c, 0xf2, 0xb7, 0xa0,
-	0xce, 0x60, 0x2b, 0x1c, 0xdb, 0xba, 0xd2, 0x96, 0x70, 0x1f, 0xfa, 0x86, 0x6c, 0x33, 0x77, 0xa1,
-	0x45, 0x40, 0xea, 0x14, 0x06, 0xb3, 0xa6, 0xcc, 0xc8, 0x58, 0xbf, 0xa2, 0x79, 0xe4, 0x95, 0x28,
-	0x09, 0x68, 0xfa, 0xd3, 0x0b, 0x4d, 0x5e, 0xc9, 0x2c, 0x8a, 0x4f, 0xc2, 0x4b, 0x88, 0x18, 0xe3,
-	0xee, 0xff, 0x02, 0xa1, 0xe7, 0xe1, 0xde, 0x3a, 0xb9, 0xca, 0x57, 0x1b, 0x78, 0x03, 0xdb, 0x4c,
-	0x3d, 0xa7, 0x7a, 0xf9, 0x56, 0x94, 0x64, 0x3b, 0x9c, 0x4f, 0x04, 0x5e, 0x81, 0x7c, 0xaa, 0x74,
-	0xde, 0x3d, 0x78, 0x24, 0xf0, 0x36, 0xbc, 0xc6, 0xfd, 0x82, 0xcc, 0xf2, 0x45, 0x53, 0xc7, 0xfb,
-	0x89, 0xb8, 0x93, 0xef, 0x03, 0x16, 0xeb, 0x2c, 0xeb, 0xf3, 0x6f, 0x5f, 0xfc, 0x06, 0x00, 0x00,
-	0xff, 0xff, 0x6c, 0x67, 0x93, 0x64, 0xfc, 0x01, 0x00, 0x00,
+	// 1061 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x55, 0xcb, 0x6e, 0xdb, 0x46,
+	0x14, 0x0d, 0xf5, 0xb0, 0xa4, 0xab, 0xe8, 0xe1, 0x6b, 0x3b, 0xa5, 0x65, 0x07, 0x71, 0x58, 0xd4,
+	0x30, 0xdc, 0x46, 0x72, 0x1d, 0x74, 0x51, 0xaf, 0x1a, 0x2b, 0xf4, 0xa3, 0xb0, 0x65, 0x81, 0x52,
+	0x0b, 0xb8, 0x45, 0x41, 0x50, 0xd4, 0x58, 0x25, 0x2c, 0x3e, 0xc2, 0x19, 0x19, 0x71, 0x8a, 0x6e,
+	0xfa, 0x0b, 0xf9, 0x88, 0xfc, 0x41, 0x57, 0x5d, 0xf6, 0x0f, 0xfa, 0x0b, 0xfd, 0x89, 0xee, 0x0a,
+	0x0e, 0x67, 0xa8, 0xa7, 0x03, 0xbb, 0xdd, 0x71, 0xee, 0xbd, 0x3c, 0x73, 0xee, 0x99, 0x33, 0x77,
+	0xa0, 0x38, 0x08, 0x09, 0x61, 0xf5, 0x20, 0xf4, 0x99, 0x8f, 0x59, 0xbe, 0xa8, 0x6d, 0x0e, 0x7c,
+	0x7f, 0x30, 0x24, 0x0d, 0x2b, 0x70, 0x1a, 0x96, 0xe7, 0xf9, 0xcc, 0x62, 0x8e, 0xef, 0xd1, 0xb8,
+	0xa8, 0xf6, 0x4c, 0x64, 0xf9, 0xaa, 0x37, 0xba, 0x6a, 0x30, 0xc7, 0x25, 0x94, 0x59, 0x6e, 0x10,
+	0x17, 0x68, 0x47, 0x90, 0x3f, 0x8e, 0x70, 0x1c, 0x6f, 0x80, 0x4f, 0x01, 0xae, 0x9c, 0x90, 0x32,
+	0xd3, 0xb3, 0x5c, 0xa2, 0x2a, 0x5b, 0xca, 0x4e, 0xc1, 0x28, 0xf0, 0x48, 0xcb, 0x72, 0x09, 0x6e,
+	0x40, 0x61, 0x68, 0xc9, 0x6c, 0x8a, 0x67, 0xf3, 0x43, 0x2b, 0x4e, 0x6a, 0xef, 0x15, 0x78, 0xcc,
+	0x81, 0x0c, 0xf2, 0x66, 0x44, 0x28, 0xc3, 0xcf, 0x21, 0x3f, 0x10, 0xc0, 0x1c, 0xaa, 0xb8, 0x5f,
+	0xa9, 0xc7, 0xf4, 0xe5, 0x7e, 0x46, 0x52, 0x80, 0xcf, 0xa0, 0x48, 0xa3, 0xff, 0x3c, 0x9b, 0x98,
+	0x4e, 0x9f, 0x83, 0x67, 0x0c, 0x90, 0xa1, 0xd3, 0x3e, 0x3e, 0x87, 0xc7, 0x21, 0xa1, 0x23, 0x97,
+	0x98, 0xcc, 0xbf, 0x26, 0x9e, 0x9a, 0xe6, 0xdb, 0x17, 0xe3, 0x58, 0x37, 0x0a, 0x21, 0x42, 0x26,
+	0xf4, 0x7d, 0x57, 0xcd, 0xf0, 0x14, 0xff, 0xd6, 0xfe, 0x51, 0xa0, 0x24, 0x58, 0xd1, 0xc0, 0xf7,
+	0x28, 0xc1, 0x27, 0xb0, 0x14, 0xfd, 0x34, 0x64, 0xa2, 0x3f, 0xb1, 0xc2, 0x6d, 0xa8, 0x58, 0xf6,
+	0xb5, 0x39, 0xcf, 0xa2, 0x64, 0xd9, 0xd7, 0x9d, 0xff, 0x4b, 0x04, 0x3f, 0x85, 0xcc, 0x55, 0xe8,
+	0xbb, 0x6a, 0x76, 0xb1, 0x12, 0x3c, 0x19, 0x49, 0x16, 0x84, 0x84, 0x46, 0x3b, 0xa9, 0x4b, 0x5b,
+	0xca, 0x4e, 0x39, 0x29, 0x6c, 0x8b, 0xb0, 0x91, 0x14, 0x44, 0x92, 0xf1, 0x9c, 0x69, 0xfb, 0x23,
+	0x8f, 0xa9, 0xb9, 0x58, 0x32, 0x1e, 0x6a, 0x46, 0x11, 0xed, 0x04, 0xd6, 0x0d, 0x42, 0x09, 0x93,
+	0x9b, 0xf0, 0xe8, 0x7f, 0x39, 0x1d, 0xad, 0x09, 0xb5, 0x45, 0x48, 0x42, 0xd1, 0xcf, 0xa0, 0x1c,
+	0x84, 0xe4, 0xc6, 0xf1, 0x47, 0x54, 0x70, 0x51, 0x62, 0xe1, 0x64, 0x34, 0xa6, 0xf3, 0x06, 0xd6,
+	0x3b, 0xa3, 0x1e, 0xb5, 0x43, 0xa7, 0x47, 0x24, 0x10, 0x95, 0x74, 0x76, 0x61, 0x79, 0xec, 0x3c,
+	0x33, 0x08, 0xc9, 0x95, 0xf3, 0x56, 0x1c, 0x50, 0x25, 0x31, 0x60, 0x9b, 0x87, 0x71, 0x07, 0xaa,
+	0x43, 0x6b, 0xa6, 0x34, 0x76, 0x63, 0x79, 0x68, 0x4d, 0x56, 0x6a, 0x7f, 0xc8, 0xd3, 0x77, 0xbc,
+	0x81, 0x7e, 0x43, 0x3c, 0x86, 0x5b, 0x90, 0x0e, 0x03, 0x9b, 0x23, 0x97, 0xf7, 0xcb, 0xa2, 0x63,
+	0x23, 0xb0, 0xbb, 0xb7, 0x01, 0x31, 0xa2, 0x14, 0xbe, 0x80, 0x82, 0xec, 0x9b, 0xaa, 0xa9, 0xad,
+	0xf4, 0x22, 0x65, 0xc6, 0x15, 0x13, 0x76, 0x4a, 0x4f, 0xd9, 0xa9, 0x0e, 0x99, 0xe8, 0xa6, 0x71,
+	0x0f, 0x14, 0xf7, 0x6b, 0xf5, 0xf8, 0x1a, 0xd6, 0xe5, 0x35, 0xac, 0x77, 0xe5, 0x35, 0x34, 0x78,
+	0x5d, 0xe4, 0x99, 0x80, 0x90, 0x90, 0xfb, 0xa3, 0x60, 0xf0, 0x6f, 0xed, 0x83, 0x02, 0xe5, 0x64,
+	0x4f, 0x62, 0xfb, 0x61, 0xff, 0x61, 0x97, 0x4a, 0x34, 0x9b, 0xba, 0xbb, 0x59, 0xc9, 0x32, 0x7d,
+	0x4f, 0x96, 0x4f, 0x60, 0xc9, 0xb6, 0x86, 0x43, 0x12, 0x0a, 0x6f, 0x8b, 0x95, 0xf6, 0xa7, 0x02,
+	0xab, 0x67, 0x0e, 0x65, 0x73, 0xe7, 0xba, 0x01, 0x85, 0xc0, 0x1a, 0x10, 0x93, 0x3a, 0xef, 0xe2,
+	0x81, 0x92, 0x35, 0xf2, 0x51, 0xa0, 0xe3, 0xbc, 0x23, 0xd1, 0xb8, 0xe1, 0xc9, 0xf8, 0x22, 0xc5,
+	0x47, 0xc8, 0xcb, 0xe3, 0x6b, 0xf4, 0x35, 0x00, 0x65, 0x56, 0xc8, 0xcc, 0x7b, 0x52, 0x2c, 0xf0,
+	0xea, 0x68, 0x8d, 0x5f, 0x41, 0x9e, 0x78, 0x7d, 0xf3, 0x9e, 0x27, 0x90, 0x23, 0x5e, 0x3f, 0x5a,
+	0x69, 0x0c, 0xd6, 0x66, 0xba, 0x10, 0x16, 0x7f, 0x39, 0x69, 0x0a, 0x85, 0x9b, 0x62, 0x6d, 0x56,
+	0x77, 0x7e, 0x40, 0x93, 0xd6, 0xd8, 0x86, 0x8a, 0x47, 0xde, 0x32, 0x73, 0xae, 0xc7, 0x52, 0x14,
+	0x6e, 0xcb, 0x3e, 0xb5, 0x43, 0x58, 0xe6, 0x20, 0x87, 0x16, 0xb3, 0x7f, 0x96, 0xc2, 0xbd, 0x98,
+	0xdf, 0xf1, 0x23, 0x36, 0xd4, 0x18, 0x14, 0xe5, 0x98, 0x8b, 0xdc, 0x87, 0x90, 0xb1, 0xfd, 0xbe,
+	0x54, 0x9c, 0x7f, 0xa3, 0x0a, 0x39, 0x97, 0x50, 0x6a, 0x0d, 0xe4, 0xec, 0x96, 0xcb, 0x3b, 0x3d,
+	0x3c, 0x33, 0x61, 0x32, 0x73, 0x13, 0xe6, 0x10, 0x70, 0x92, 0xb9, 0x10, 0xeb, 0x0b, 0xc8, 0xc5,
+	0x00, 0x92, 0x38, 0x4e, 0x12, 0x8f, 0x19, 0x1a, 0xb2, 0x64, 0xf7, 0x0c, 0xf2, 0x72, 0xb8, 0xa1,
+	0x0a, 0xab, 0x6d, 0x43, 0xef, 0xe8, 0xad, 0xa6, 0x6e, 0x7e, 0xd7, 0xea, 0xb4, 0xf5, 0xe6, 0xe9,
+	0xd1, 0xa9, 0xfe, 0xba, 0xfa, 0x08, 0x57, 0xa0, 0x92, 0x64, 0xbe, 0xbd, 0x38, 0x6d, 0xe9, 0xaf,
+	0xab, 0x0a, 0x2e, 0x43, 0x29, 0x09, 0x9e, 0xe9, 0x47, 0xdd, 0x6a, 0x6a, 0xf7, 0x83, 0x02, 0x39,
+	0xe1, 0xf0, 0x08, 0xcd, 0x68, 0x37, 0xcd, 0xee, 0x65, 0x7b, 0x16, 0x0d, 0xa1, 0x9c, 0x64, 0x8e,
+	0x0d, 0x5d, 0xef, 0x56, 0x15, 0xfc, 0x04, 0x56, 0x92, 0xd8, 0xd9, 0x45, 0xeb, 0x58, 0x24, 0x52,
+	0xb8, 0x09, 0xea, 0x74, 0xb1, 0xa9, 0x7f, 0xaf, 0x1b, 0x97, 0xe6, 0x45, 0x4b, 0xaf, 0xa6, 0xf1,
+	0x29, 0xac, 0xcf, 0x64, 0xcf, 0x5f, 0xb5, 0x2e, 0xcd, 0xee, 0xe9, 0xb9, 0xde, 0xa9, 0x66, 0xa6,
+	0x38, 0xc4, 0xe9, 0xc3, 0x57, 0xdd, 0xe6, 0x49, 0x35, 0xbb, 0xff, 0x7b, 0x56, 0xbc, 0x97, 0x1d,
+	0x12, 0xde, 0x38, 0x36, 0xc1, 0x13, 0xc8, 0xf2, 0x35, 0xae, 0x4c, 0xcb, 0xc5, 0xfd, 0x50, 0x5b,
+	0x9d, 0xd1, 0x90, 0x4b, 0xad, 0xad, 0xfe, 0xf6, 0xd7, 0xdf, 0xef, 0x53, 0x65, 0xad, 0xd0, 0xb8,
+	0xf9, 0xb2, 0xc1, 0x0b, 0x0e, 0x94, 0x5d, 0x74, 0xc5, 0xd8, 0x38, 0xb7, 0xbc, 0x5b, 0xee, 0xf2,
+	0x87, 0x40, 0xd6, 0x39, 0xe4, 0x0e, 0x6e, 0x27, 0x90, 0x8d, 0x5f, 0xa4, 0xcf, 0xea, 0xe3, 0x19,
+	0xfd, 0x6b, 0x83, 0xb2, 0x90, 0x58, 0xee, 0x9e, 0x82, 0x07, 0x50, 0x38, 0xf3, 0xbd, 0xc1, 0x83,
+	0xc9, 0x3f, 0xda, 0x51, 0xf0, 0x1b, 0x31, 0xa0, 0xf5, 0x1b, 0x12, 0xde, 0x5e, 0x78, 0xe4, 0x81,
+	0xff, 0xef, 0x29, 0xd8, 0x06, 0x9c, 0x7f, 0x56, 0x70, 0x4b, 0xfc, 0x71, 0xe7, 0x8b, 0x33, 0x8d,
+	0x29, 0xdf, 0x07, 0xed, 0xd1, 0x9e, 0x82, 0x16, 0x94, 0xa6, 0xa6, 0x00, 0x6e, 0x88, 0xd2, 0x45,
+	0x13, 0xae, 0xb6, 0xb9, 0x38, 0x29, 0x38, 0xae, 0x71, 0x35, 0x2b, 0x58, 0x4a, 0xd4, 0xe4, 0x88,
+	0x3f, 0x02, 0xce, 0x3f, 0xa8, 0x09, 0xe9, 0x3b, 0x5f, 0xed, 0xda, 0xf3, 0x8f, 0x54, 0x48, 0x55,
+	0xf0, 0x27, 0x80, 0xf1, 0xad, 0x44, 0x75, 0xb2, 0xcf, 0xc9, 0x11, 0x53, 0x5b, 0x5f, 0x90, 0x11,
+	0x20, 0x35, 0x4e, 0x7b, 0x55, 0xab, 0x8c, 0x7d, 0xd5, 0x8b, 0x0a, 0x0e, 0x94, 0xdd, 0xc3, 0xc2,
+	0x0f, 0x39, 0x1e, 0x09, 0x7a, 0xbd, 0x25, 0x3e, 0x4c, 0x5f, 0xfe, 0x3b, 0x00, 0xd7, 0x38, 0x55,
+	0x26, 0x97, 0x0a, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -238,6 +944,14 @@ type GreetServiceClient interface {
 	LongGreet(ctx context.Context, opts ...grpc.CallOption) (GreetService_LongGreetClient, error)
 	// Bi-Directional Stream
 	GreetEveryOne(ctx context.Context, opts ...grpc.CallOption) (GreetService_GreetEveryOneClient, error)
+	// Server Stream of the greetings handled from now on
+	SubscribeGreetings(ctx context.Context, in *SubscribeGreetingsRequest, opts ...grpc.CallOption) (GreetService_SubscribeGreetingsClient, error)
+	// Unary query of the greeting history
+	ListGreetings(ctx context.Context, in *ListGreetingsRequest, opts ...grpc.CallOption) (*ListGreetingsResponse, error)
+	// Unary, for admins: start a person's greet_count again from zero
+	ResetGreetingCount(ctx context.Context, in *ResetGreetingCountRequest, opts ...grpc.CallOption) (*ResetGreetingCountResponse, error)
+	// Unary greeting of many people in one round trip
+	GreetBatch(ctx context.Context, in *GreetBatchRequest, opts ...grpc.CallOption) (*GreetBatchResponse, error)
 }
 
 type greetServiceClient struct {
@@ -354,6 +1068,65 @@ func (x *greetServiceGreetEveryOneClient) Recv() (*GreetResponse, error) {
 	return m, nil
 }
 
+func (c *greetServiceClient) SubscribeGreetings(ctx context.Context, in *SubscribeGreetingsRequest, opts ...grpc.CallOption) (GreetService_SubscribeGreetingsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_GreetService_serviceDesc.Streams[3], "/greet.GreetService/SubscribeGreetings", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &greetServiceSubscribeGreetingsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type GreetService_SubscribeGreetingsClient interface {
+	Recv() (*GreetingEvent, error)
+	grpc.ClientStream
+}
+
+type greetServiceSubscribeGreetingsClient struct {
+	grpc.ClientStream
+}
+
+func (x *greetServiceSubscribeGreetingsClient) Recv() (*GreetingEvent, error) {
+	m := new(GreetingEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *greetServiceClient) ListGreetings(ctx context.Context, in *ListGreetingsRequest, opts ...grpc.CallOption) (*ListGreetingsResponse, error) {
+	out := new(ListGreetingsResponse)
+	err := c.cc.Invoke(ctx, "/greet.GreetService/ListGreetings", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *greetServiceClient) ResetGreetingCount(ctx context.Context, in *ResetGreetingCountRequest, opts ...grpc.CallOption) (*ResetGreetingCountResponse, error) {
+	out := new(ResetGreetingCountResponse)
+	err := c.cc.Invoke(ctx, "/greet.GreetService/ResetGreetingCount", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *greetServiceClient) GreetBatch(ctx context.Context, in *GreetBatchRequest, opts ...grpc.CallOption) (*GreetBatchResponse, error) {
+	out := new(GreetBatchResponse)
+	err := c.cc.Invoke(ctx, "/greet.GreetService/GreetBatch", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // GreetServiceServer is the server API for GreetService service.
 type GreetServiceServer interface {
 	// Unary
@@ -364,6 +1137,14 @@ type GreetServiceServer interface {
 	LongGreet(GreetService_LongGreetServer) error
 	// Bi-Directional Stream
 	GreetEveryOne(GreetService_GreetEveryOneServer) error
+	// Server Stream of the greetings handled from now on
+	SubscribeGreetings(*SubscribeGreetingsRequest, GreetService_SubscribeGreetingsServer) error
+	// Unary query of the greeting history
+	ListGreetings(context.Context, *ListGreetingsRequest) (*ListGreetingsResponse, error)
+	// Unary, for admins: start a person's greet_count again from zero
+	ResetGreetingCount(context.Context, *ResetGreetingCountRequest) (*ResetGreetingCountResponse, error)
+	// Unary greeting of many people in one round trip
+	GreetBatch(context.Context, *GreetBatchRequest) (*GreetBatchResponse, error)
 }
 
 // UnimplementedGreetServiceServer can be embedded to have forward compatible implementations.
@@ -382,6 +1163,18 @@ func (*UnimplementedGreetServiceServer) LongGreet(srv GreetService_LongGreetServ
 func (*UnimplementedGreetServiceServer) GreetEveryOne(srv GreetService_GreetEveryOneServer) error {
 	return status.Errorf(codes.Unimplemented, "method GreetEveryOne not implemented")
 }
+func (*UnimplementedGreetServiceServer) SubscribeGreetings(req *SubscribeGreetingsRequest, srv GreetService_SubscribeGreetingsServer) error {
+	return status.Errorf(codes.Unimplemented, "method SubscribeGreetings not implemented")
+}
+func (*UnimplementedGreetServiceServer) ListGreetings(ctx context.Context, req *ListGreetingsRequest) (*ListGreetingsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListGreetings not implemented")
+}
+func (*UnimplementedGreetServiceServer) ResetGreetingCount(ctx context.Context, req *ResetGreetingCountRequest) (*ResetGreetingCountResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResetGreetingCount not implemented")
+}
+func (*UnimplementedGreetServiceServer) GreetBatch(ctx context.Context, req *GreetBatchRequest) (*GreetBatchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GreetBatch not implemented")
+}
 
 func RegisterGreetServiceServer(s *grpc.Server, srv GreetServiceServer) {
 	s.RegisterService(&_GreetService_serviceDesc, srv)
@@ -478,6 +1271,81 @@ func (x *greetServiceGreetEveryOneServer) Recv() (*GreetRequest, error) {
 	return m, nil
 }
 
+func _GreetService_SubscribeGreetings_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeGreetingsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(GreetServiceServer).SubscribeGreetings(m, &greetServiceSubscribeGreetingsServer{stream})
+}
+
+type GreetService_SubscribeGreetingsServer interface {
+	Send(*GreetingEvent) error
+	grpc.ServerStream
+}
+
+type greetServiceSubscribeGreetingsServer struct {
+	grpc.ServerStream
+}
+
+func (x *greetServiceSubscribeGreetingsServer) Send(m *GreetingEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _GreetService_ListGreetings_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListGreetingsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GreetServiceServer).ListGreetings(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/greet.GreetService/ListGreetings",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GreetServiceServer).ListGreetings(ctx, req.(*ListGreetingsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GreetService_ResetGreetingCount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResetGreetingCountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GreetServiceServer).ResetGreetingCount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/greet.GreetService/ResetGreetingCount",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GreetServiceServer).ResetGreetingCount(ctx, req.(*ResetGreetingCountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GreetService_GreetBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GreetBatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GreetServiceServer).GreetBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/greet.GreetService/GreetBatch",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GreetServiceServer).GreetBatch(ctx, req.(*GreetBatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _GreetService_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "greet.GreetService",
 	HandlerType: (*GreetServiceServer)(nil),
@@ -486,6 +1354,18 @@ var _GreetService_serviceDesc = grpc.ServiceDesc{
 			MethodName: "Greet",
 			Handler:    _GreetService_Greet_Handler,
 		},
+		{
+			MethodName: "ListGreetings",
+			Handler:    _GreetService_ListGreetings_Handler,
+		},
+		{
+			MethodName: "ResetGreetingCount",
+			Handler:    _GreetService_ResetGreetingCount_Handler,
+		},
+		{
+			MethodName: "GreetBatch",
+			Handler:    _GreetService_GreetBatch_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -504,6 +1384,11 @@ var _GreetService_serviceDesc = grpc.ServiceDesc{
 			ServerStreams: true,
 			ClientStreams: true,
 		},
+		{
+			StreamName:    "SubscribeGreetings",
+			Handler:       _GreetService_SubscribeGreetings_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "greet.proto",
 }
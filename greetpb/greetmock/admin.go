@@ -0,0 +1,88 @@
+package greetmock
+
+import (
+	"context"
+
+	greetpb ".."
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// AdminClient implements greetpb.GreetAdminClient. Each method delegates to
+// the matching func field and returns codes.Unimplemented when it is nil.
+type AdminClient struct {
+	GetServerStatusFunc func(ctx context.Context, req *greetpb.GetServerStatusRequest) (*greetpb.ServerStatus, error)
+	ListPeersFunc       func(ctx context.Context, req *greetpb.ListPeersRequest) (*greetpb.ListPeersResponse, error)
+	GetConfigFunc       func(ctx context.Context, req *greetpb.GetConfigRequest) (*greetpb.GetConfigResponse, error)
+	SetLogLevelFunc     func(ctx context.Context, req *greetpb.SetLogLevelRequest) (*greetpb.SetLogLevelResponse, error)
+}
+
+var _ greetpb.GreetAdminClient = (*AdminClient)(nil)
+
+func (c *AdminClient) GetServerStatus(ctx context.Context, req *greetpb.GetServerStatusRequest, opts ...grpc.CallOption) (*greetpb.ServerStatus, error) {
+	if c.GetServerStatusFunc == nil {
+		return nil, status.Error(codes.Unimplemented, "greetmock: GetServerStatus not scripted")
+	}
+	return c.GetServerStatusFunc(ctx, req)
+}
+
+func (c *AdminClient) ListPeers(ctx context.Context, req *greetpb.ListPeersRequest, opts ...grpc.CallOption) (*greetpb.ListPeersResponse, error) {
+	if c.ListPeersFunc == nil {
+		return nil, status.Error(codes.Unimplemented, "greetmock: ListPeers not scripted")
+	}
+	return c.ListPeersFunc(ctx, req)
+}
+
+func (c *AdminClient) GetConfig(ctx context.Context, req *greetpb.GetConfigRequest, opts ...grpc.CallOption) (*greetpb.GetConfigResponse, error) {
+	if c.GetConfigFunc == nil {
+		return nil, status.Error(codes.Unimplemented, "greetmock: GetConfig not scripted")
+	}
+	return c.GetConfigFunc(ctx, req)
+}
+
+func (c *AdminClient) SetLogLevel(ctx context.Context, req *greetpb.SetLogLevelRequest, opts ...grpc.CallOption) (*greetpb.SetLogLevelResponse, error) {
+	if c.SetLogLevelFunc == nil {
+		return nil, status.Error(codes.Unimplemented, "greetmock: SetLogLevel not scripted")
+	}
+	return c.SetLogLevelFunc(ctx, req)
+}
+
+// AdminServer implements greetpb.GreetAdminServer by delegating to its func
+// fields, returning codes.Unimplemented for any that are nil.
+type AdminServer struct {
+	GetServerStatusFunc func(ctx context.Context, req *greetpb.GetServerStatusRequest) (*greetpb.ServerStatus, error)
+	ListPeersFunc       func(ctx context.Context, req *greetpb.ListPeersRequest) (*greetpb.ListPeersResponse, error)
+	GetConfigFunc       func(ctx context.Context, req *greetpb.GetConfigRequest) (*greetpb.GetConfigResponse, error)
+	SetLogLevelFunc     func(ctx context.Context, req *greetpb.SetLogLevelRequest) (*greetpb.SetLogLevelResponse, error)
+}
+
+var _ greetpb.GreetAdminServer = (*AdminServer)(nil)
+
+func (s *AdminServer) GetServerStatus(ctx context.Context, req *greetpb.GetServerStatusRequest) (*greetpb.ServerStatus, error) {
+	if s.GetServerStatusFunc == nil {
+		return nil, status.Error(codes.Unimplemented, "greetmock: GetServerStatus not scripted")
+	}
+	return s.GetServerStatusFunc(ctx, req)
+}
+
+func (s *AdminServer) ListPeers(ctx context.Context, req *greetpb.ListPeersRequest) (*greetpb.ListPeersResponse, error) {
+	if s.ListPeersFunc == nil {
+		return nil, status.Error(codes.Unimplemented, "greetmock: ListPeers not scripted")
+	}
+	return s.ListPeersFunc(ctx, req)
+}
+
+func (s *AdminServer) GetConfig(ctx context.Context, req *greetpb.GetConfigRequest) (*greetpb.GetConfigResponse, error) {
+	if s.GetConfigFunc == nil {
+		return nil, status.Error(codes.Unimplemented, "greetmock: GetConfig not scripted")
+	}
+	return s.GetConfigFunc(ctx, req)
+}
+
+func (s *AdminServer) SetLogLevel(ctx context.Context, req *greetpb.SetLogLevelRequest) (*greetpb.SetLogLevelResponse, error) {
+	if s.SetLogLevelFunc == nil {
+		return nil, status.Error(codes.Unimplemented, "greetmock: SetLogLevel not scripted")
+	}
+	return s.SetLogLevelFunc(ctx, req)
+}
@@ -0,0 +1,92 @@
+// Package greetmock provides scriptable fakes of the GreetService and
+// GreetAdmin clients and servers for unit tests that should not depend on a
+// running greet_server.
+package greetmock
+
+import (
+	"context"
+
+	greetpb ".."
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Client implements greetpb.GreetServiceClient. Each method delegates to the
+// matching func field and returns codes.Unimplemented when it is nil.
+type Client struct {
+	GreetFunc              func(ctx context.Context, req *greetpb.GreetRequest) (*greetpb.GreetResponse, error)
+	GreetManyTimesFunc     func(ctx context.Context, req *greetpb.GreetRequest) (greetpb.GreetService_GreetManyTimesClient, error)
+	LongGreetFunc          func(ctx context.Context) (greetpb.GreetService_LongGreetClient, error)
+	GreetEveryOneFunc      func(ctx context.Context) (greetpb.GreetService_GreetEveryOneClient, error)
+	SubscribeGreetingsFunc func(ctx context.Context, req *greetpb.SubscribeGreetingsRequest) (greetpb.GreetService_SubscribeGreetingsClient, error)
+	ListGreetingsFunc      func(ctx context.Context, req *greetpb.ListGreetingsRequest) (*greetpb.ListGreetingsResponse, error)
+	ResetGreetingCountFunc func(ctx context.Context, req *greetpb.ResetGreetingCountRequest) (*greetpb.ResetGreetingCountResponse, error)
+	GreetBatchFunc         func(ctx context.Context, req *greetpb.GreetBatchRequest) (*greetpb.GreetBatchResponse, error)
+}
+
+var _ greetpb.GreetServiceClient = (*Client)(nil)
+
+// Unary
+func (c *Client) Greet(ctx context.Context, req *greetpb.GreetRequest, opts ...grpc.CallOption) (*greetpb.GreetResponse, error) {
+	if c.GreetFunc == nil {
+		return nil, status.Error(codes.Unimplemented, "greetmock: Greet not scripted")
+	}
+	return c.GreetFunc(ctx, req)
+}
+
+// Server Stream
+func (c *Client) GreetManyTimes(ctx context.Context, req *greetpb.GreetRequest, opts ...grpc.CallOption) (greetpb.GreetService_GreetManyTimesClient, error) {
+	if c.GreetManyTimesFunc == nil {
+		return nil, status.Error(codes.Unimplemented, "greetmock: GreetManyTimes not scripted")
+	}
+	return c.GreetManyTimesFunc(ctx, req)
+}
+
+// Client Stream
+func (c *Client) LongGreet(ctx context.Context, opts ...grpc.CallOption) (greetpb.GreetService_LongGreetClient, error) {
+	if c.LongGreetFunc == nil {
+		return nil, status.Error(codes.Unimplemented, "greetmock: LongGreet not scripted")
+	}
+	return c.LongGreetFunc(ctx)
+}
+
+// Bi-Directional Stream
+func (c *Client) GreetEveryOne(ctx context.Context, opts ...grpc.CallOption) (greetpb.GreetService_GreetEveryOneClient, error) {
+	if c.GreetEveryOneFunc == nil {
+		return nil, status.Error(codes.Unimplemented, "greetmock: GreetEveryOne not scripted")
+	}
+	return c.GreetEveryOneFunc(ctx)
+}
+
+// Server Stream
+func (c *Client) SubscribeGreetings(ctx context.Context, req *greetpb.SubscribeGreetingsRequest, opts ...grpc.CallOption) (greetpb.GreetService_SubscribeGreetingsClient, error) {
+	if c.SubscribeGreetingsFunc == nil {
+		return nil, status.Error(codes.Unimplemented, "greetmock: SubscribeGreetings not scripted")
+	}
+	return c.SubscribeGreetingsFunc(ctx, req)
+}
+
+// Unary
+func (c *Client) ListGreetings(ctx context.Context, req *greetpb.ListGreetingsRequest, opts ...grpc.CallOption) (*greetpb.ListGreetingsResponse, error) {
+	if c.ListGreetingsFunc == nil {
+		return nil, status.Error(codes.Unimplemented, "greetmock: ListGreetings not scripted")
+	}
+	return c.ListGreetingsFunc(ctx, req)
+}
+
+// Unary
+func (c *Client) ResetGreetingCount(ctx context.Context, req *greetpb.ResetGreetingCountRequest, opts ...grpc.CallOption) (*greetpb.ResetGreetingCountResponse, error) {
+	if c.ResetGreetingCountFunc == nil {
+		return nil, status.Error(codes.Unimplemented, "greetmock: ResetGreetingCount not scripted")
+	}
+	return c.ResetGreetingCountFunc(ctx, req)
+}
+
+// Unary
+func (c *Client) GreetBatch(ctx context.Context, req *greetpb.GreetBatchRequest, opts ...grpc.CallOption) (*greetpb.GreetBatchResponse, error) {
+	if c.GreetBatchFunc == nil {
+		return nil, status.Error(codes.Unimplemented, "greetmock: GreetBatch not scripted")
+	}
+	return c.GreetBatchFunc(ctx, req)
+}
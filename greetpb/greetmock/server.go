@@ -0,0 +1,89 @@
+package greetmock
+
+import (
+	"context"
+
+	greetpb ".."
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Server implements greetpb.GreetServiceServer by delegating to its func
+// fields, returning codes.Unimplemented for any that are nil. It can be
+// served in-process with greettest.NewServer.
+type Server struct {
+	GreetFunc              func(ctx context.Context, req *greetpb.GreetRequest) (*greetpb.GreetResponse, error)
+	GreetManyTimesFunc     func(req *greetpb.GreetRequest, stream greetpb.GreetService_GreetManyTimesServer) error
+	LongGreetFunc          func(stream greetpb.GreetService_LongGreetServer) error
+	GreetEveryOneFunc      func(stream greetpb.GreetService_GreetEveryOneServer) error
+	SubscribeGreetingsFunc func(req *greetpb.SubscribeGreetingsRequest, stream greetpb.GreetService_SubscribeGreetingsServer) error
+	ListGreetingsFunc      func(ctx context.Context, req *greetpb.ListGreetingsRequest) (*greetpb.ListGreetingsResponse, error)
+	ResetGreetingCountFunc func(ctx context.Context, req *greetpb.ResetGreetingCountRequest) (*greetpb.ResetGreetingCountResponse, error)
+	GreetBatchFunc         func(ctx context.Context, req *greetpb.GreetBatchRequest) (*greetpb.GreetBatchResponse, error)
+}
+
+var _ greetpb.GreetServiceServer = (*Server)(nil)
+
+// Unary
+func (s *Server) Greet(ctx context.Context, req *greetpb.GreetRequest) (*greetpb.GreetResponse, error) {
+	if s.GreetFunc == nil {
+		return nil, status.Error(codes.Unimplemented, "greetmock: Greet not scripted")
+	}
+	return s.GreetFunc(ctx, req)
+}
+
+// Server Stream
+func (s *Server) GreetManyTimes(req *greetpb.GreetRequest, stream greetpb.GreetService_GreetManyTimesServer) error {
+	if s.GreetManyTimesFunc == nil {
+		return status.Error(codes.Unimplemented, "greetmock: GreetManyTimes not scripted")
+	}
+	return s.GreetManyTimesFunc(req, stream)
+}
+
+// Client Stream
+func (s *Server) LongGreet(stream greetpb.GreetService_LongGreetServer) error {
+	if s.LongGreetFunc == nil {
+		return status.Error(codes.Unimplemented, "greetmock: LongGreet not scripted")
+	}
+	return s.LongGreetFunc(stream)
+}
+
+// Bi-Directional Stream
+func (s *Server) GreetEveryOne(stream greetpb.GreetService_GreetEveryOneServer) error {
+	if s.GreetEveryOneFunc == nil {
+		return status.Error(codes.Unimplemented, "greetmock: GreetEveryOne not scripted")
+	}
+	return s.GreetEveryOneFunc(stream)
+}
+
+// Server Stream
+func (s *Server) SubscribeGreetings(req *greetpb.SubscribeGreetingsRequest, stream greetpb.GreetService_SubscribeGreetingsServer) error {
+	if s.SubscribeGreetingsFunc == nil {
+		return status.Error(codes.Unimplemented, "greetmock: SubscribeGreetings not scripted")
+	}
+	return s.SubscribeGreetingsFunc(req, stream)
+}
+
+// Unary
+func (s *Server) ListGreetings(ctx context.Context, req *greetpb.ListGreetingsRequest) (*greetpb.ListGreetingsResponse, error) {
+	if s.ListGreetingsFunc == nil {
+		return nil, status.Error(codes.Unimplemented, "greetmock: ListGreetings not scripted")
+	}
+	return s.ListGreetingsFunc(ctx, req)
+}
+
+// Unary
+func (s *Server) ResetGreetingCount(ctx context.Context, req *greetpb.ResetGreetingCountRequest) (*greetpb.ResetGreetingCountResponse, error) {
+	if s.ResetGreetingCountFunc == nil {
+		return nil, status.Error(codes.Unimplemented, "greetmock: ResetGreetingCount not scripted")
+	}
+	return s.ResetGreetingCountFunc(ctx, req)
+}
+
+// Unary
+func (s *Server) GreetBatch(ctx context.Context, req *greetpb.GreetBatchRequest) (*greetpb.GreetBatchResponse, error) {
+	if s.GreetBatchFunc == nil {
+		return nil, status.Error(codes.Unimplemented, "greetmock: GreetBatch not scripted")
+	}
+	return s.GreetBatchFunc(ctx, req)
+}
@@ -0,0 +1,216 @@
+package greetmock
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	greetpb ".."
+	"google.golang.org/grpc/metadata"
+)
+
+// ClientStream implements the grpc.ClientStream methods shared by the fake
+// streams. A nil Ctx is treated as context.Background().
+type ClientStream struct {
+	Ctx       context.Context
+	HeaderMD  metadata.MD
+	TrailerMD metadata.MD
+}
+
+func (s *ClientStream) Header() (metadata.MD, error) { return s.HeaderMD, nil }
+func (s *ClientStream) Trailer() metadata.MD         { return s.TrailerMD }
+func (s *ClientStream) SendMsg(m interface{}) error  { return nil }
+func (s *ClientStream) RecvMsg(m interface{}) error  { return nil }
+
+func (s *ClientStream) Context() context.Context {
+	if s.Ctx == nil {
+		return context.Background()
+	}
+	return s.Ctx
+}
+
+// GreetManyTimesClient is a fake server stream that returns Responses in
+// order, then Err, or io.EOF when Err is nil.
+type GreetManyTimesClient struct {
+	ClientStream
+	Responses []*greetpb.GreetResponse
+	Err       error
+
+	mu   sync.Mutex
+	next int
+}
+
+var _ greetpb.GreetService_GreetManyTimesClient = (*GreetManyTimesClient)(nil)
+
+func (s *GreetManyTimesClient) Recv() (*greetpb.GreetResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.next < len(s.Responses) {
+		res := s.Responses[s.next]
+		s.next++
+		return res, nil
+	}
+	if s.Err != nil {
+		return nil, s.Err
+	}
+	return nil, io.EOF
+}
+
+func (s *GreetManyTimesClient) CloseSend() error { return nil }
+
+// SubscribeGreetingsClient is a fake server stream that returns Events in
+// order, then Err, or io.EOF when Err is nil.
+type SubscribeGreetingsClient struct {
+	ClientStream
+	Events []*greetpb.GreetingEvent
+	Err    error
+
+	mu   sync.Mutex
+	next int
+}
+
+var _ greetpb.GreetService_SubscribeGreetingsClient = (*SubscribeGreetingsClient)(nil)
+
+func (s *SubscribeGreetingsClient) Recv() (*greetpb.GreetingEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.next < len(s.Events) {
+		ev := s.Events[s.next]
+		s.next++
+		return ev, nil
+	}
+	if s.Err != nil {
+		return nil, s.Err
+	}
+	return nil, io.EOF
+}
+
+func (s *SubscribeGreetingsClient) CloseSend() error { return nil }
+
+// LongGreetClient is a fake client stream. Send records requests or fails
+// with SendErr; CloseAndRecv returns Response and Err.
+type LongGreetClient struct {
+	ClientStream
+	Response *greetpb.GreetResponse
+	Err      error
+	SendErr  error
+
+	mu   sync.Mutex
+	sent []*greetpb.GreetRequest
+}
+
+var _ greetpb.GreetService_LongGreetClient = (*LongGreetClient)(nil)
+
+func (s *LongGreetClient) Send(req *greetpb.GreetRequest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.SendErr != nil {
+		return s.SendErr
+	}
+	s.sent = append(s.sent, req)
+	return nil
+}
+
+func (s *LongGreetClient) CloseAndRecv() (*greetpb.GreetResponse, error) {
+	if s.Err != nil {
+		return nil, s.Err
+	}
+	return s.Response, nil
+}
+
+func (s *LongGreetClient) CloseSend() error { return nil }
+
+// Sent returns the requests passed to Send so far.
+func (s *LongGreetClient) Sent() []*greetpb.GreetRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*greetpb.GreetRequest(nil), s.sent...)
+}
+
+// GreetEveryOneClient is a fake bi-directional stream. Responses are queued
+// for Recv up front; Reply, when set, queues one more response (or error)
+// for every request passed to Send. Once CloseSend has been called and the
+// queue is drained, Recv returns Err, or io.EOF when Err is nil.
+type GreetEveryOneClient struct {
+	ClientStream
+	Responses []*greetpb.GreetResponse
+	Reply     func(req *greetpb.GreetRequest) (*greetpb.GreetResponse, error)
+	Err       error
+	SendErr   error
+
+	once   sync.Once
+	mu     sync.Mutex
+	cond   *sync.Cond
+	queue  []reply
+	sent   []*greetpb.GreetRequest
+	closed bool
+}
+
+type reply struct {
+	res *greetpb.GreetResponse
+	err error
+}
+
+var _ greetpb.GreetService_GreetEveryOneClient = (*GreetEveryOneClient)(nil)
+
+func (s *GreetEveryOneClient) init() {
+	s.once.Do(func() {
+		s.cond = sync.NewCond(&s.mu)
+		for _, res := range s.Responses {
+			s.queue = append(s.queue, reply{res: res})
+		}
+	})
+}
+
+func (s *GreetEveryOneClient) Send(req *greetpb.GreetRequest) error {
+	s.init()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.SendErr != nil {
+		return s.SendErr
+	}
+	if s.closed {
+		return io.EOF
+	}
+	s.sent = append(s.sent, req)
+	if s.Reply != nil {
+		res, err := s.Reply(req)
+		s.queue = append(s.queue, reply{res: res, err: err})
+		s.cond.Broadcast()
+	}
+	return nil
+}
+
+func (s *GreetEveryOneClient) Recv() (*greetpb.GreetResponse, error) {
+	s.init()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for len(s.queue) == 0 && !s.closed {
+		s.cond.Wait()
+	}
+	if len(s.queue) > 0 {
+		r := s.queue[0]
+		s.queue = s.queue[1:]
+		return r.res, r.err
+	}
+	if s.Err != nil {
+		return nil, s.Err
+	}
+	return nil, io.EOF
+}
+
+func (s *GreetEveryOneClient) CloseSend() error {
+	s.init()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	s.cond.Broadcast()
+	return nil
+}
+
+// Sent returns the requests passed to Send so far.
+func (s *GreetEveryOneClient) Sent() []*greetpb.GreetRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*greetpb.GreetRequest(nil), s.sent...)
+}
@@ -0,0 +1,164 @@
+package greetserver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"../greetpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// countingGreet is a Greet handler whose GreetCount is the number of times
+// it ran.
+type countingGreet struct {
+	calls int
+	err   error
+}
+
+func (h *countingGreet) handle(ctx context.Context, req interface{}) (interface{}, error) {
+	h.calls++
+	if h.err != nil {
+		return nil, h.err
+	}
+	return &greetpb.GreetResponse{
+		Result:     "Hi " + req.(*greetpb.GreetRequest).GetGreeting().GetFirstName(),
+		GreetCount: uint64(h.calls),
+	}, nil
+}
+
+// greetThrough calls Greet for firstName through c, with the given
+// cache-control metadata if any.
+func greetThrough(t *testing.T, c *ResponseCache, h *countingGreet, firstName string, cacheControl ...string) *greetpb.GreetResponse {
+	t.Helper()
+	ctx := context.Background()
+	if len(cacheControl) > 0 {
+		ctx = metadata.NewIncomingContext(ctx, metadata.MD{CacheControlHeader: cacheControl})
+	}
+	req := &greetpb.GreetRequest{Greeting: &greetpb.Greeting{FirstName: firstName}}
+	res, err := c.UnaryServerInterceptor(ctx, req, &grpc.UnaryServerInfo{FullMethod: greetMethod}, h.handle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res.(*greetpb.GreetResponse)
+}
+
+func TestCacheHitAndMiss(t *testing.T) {
+	c, h := NewResponseCache(10, time.Minute), &countingGreet{}
+	first := greetThrough(t, c, h, "Nanda")
+	first.Result = "changed by the caller"
+	if res := greetThrough(t, c, h, "Nanda"); res.GetResult() != "Hi Nanda" || res.GetGreetCount() != 1 {
+		t.Errorf("hit = %v, want the first response", res)
+	}
+	if res := greetThrough(t, c, h, "Kumar"); res.GetGreetCount() != 2 {
+		t.Errorf("another request = %v, want a fresh response", res)
+	}
+	if h.calls != 2 {
+		t.Errorf("handler ran %d times, want 2", h.calls)
+	}
+	if got := metricsOf(t, c); got["hits"] != 1 || got["misses"] != 2 || got["entries"] != 2 {
+		t.Errorf("metrics = %v", got)
+	}
+}
+
+func TestCachePassesThroughOtherCalls(t *testing.T) {
+	c, h := NewResponseCache(10, time.Minute), &countingGreet{}
+	req := &greetpb.GreetRequest{Greeting: &greetpb.Greeting{FirstName: "Nanda"}}
+	info := &grpc.UnaryServerInfo{FullMethod: "/greet.GreetService/GreetBatch"}
+	for i := 0; i < 2; i++ {
+		c.UnaryServerInterceptor(context.Background(), req, info, h.handle)
+	}
+	if h.calls != 2 {
+		t.Errorf("other method: handler ran %d times, want 2", h.calls)
+	}
+
+	h = &countingGreet{err: status.Error(codes.Internal, "boom")}
+	info = &grpc.UnaryServerInfo{FullMethod: greetMethod}
+	for i := 0; i < 2; i++ {
+		if _, err := c.UnaryServerInterceptor(context.Background(), req, info, h.handle); status.Code(err) != codes.Internal {
+			t.Fatalf("failed call: %v, want the handler's error", err)
+		}
+	}
+	if h.calls != 2 {
+		t.Errorf("failed call: handler ran %d times, want 2", h.calls)
+	}
+}
+
+func TestCacheExpires(t *testing.T) {
+	c, h := NewResponseCache(10, 20*time.Millisecond), &countingGreet{}
+	greetThrough(t, c, h, "Nanda")
+	greetThrough(t, c, h, "Nanda")
+	time.Sleep(40 * time.Millisecond)
+	if res := greetThrough(t, c, h, "Nanda"); res.GetGreetCount() != 2 {
+		t.Errorf("after the ttl = %v, want a fresh response", res)
+	}
+}
+
+func TestCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	c, h := NewResponseCache(2, time.Minute), &countingGreet{}
+	greetThrough(t, c, h, "a")
+	greetThrough(t, c, h, "b")
+	greetThrough(t, c, h, "a") // a is now the most recently used.
+	greetThrough(t, c, h, "c") // evicts b.
+	if h.calls != 3 {
+		t.Fatalf("handler ran %d times, want 3", h.calls)
+	}
+	greetThrough(t, c, h, "a")
+	if h.calls != 3 {
+		t.Errorf("a was evicted")
+	}
+	greetThrough(t, c, h, "b")
+	if h.calls != 4 {
+		t.Errorf("b was kept past the size of the cache")
+	}
+}
+
+func TestCacheNoCacheAndNoStore(t *testing.T) {
+	c, h := NewResponseCache(10, time.Minute), &countingGreet{}
+	greetThrough(t, c, h, "Nanda")
+
+	// no-cache skips the cached response but stores the fresh one.
+	if res := greetThrough(t, c, h, "Nanda", "no-cache"); res.GetGreetCount() != 2 {
+		t.Errorf("no-cache = %v, want a fresh response", res)
+	}
+	if res := greetThrough(t, c, h, "Nanda"); res.GetGreetCount() != 2 {
+		t.Errorf("after no-cache = %v, want the response it stored", res)
+	}
+
+	// no-store skips the cache and leaves it as it was.
+	if res := greetThrough(t, c, h, "Nanda", "no-store"); res.GetGreetCount() != 3 {
+		t.Errorf("no-store = %v, want a fresh response", res)
+	}
+	if res := greetThrough(t, c, h, "Nanda"); res.GetGreetCount() != 2 {
+		t.Errorf("after no-store = %v, want the response stored before", res)
+	}
+	if got := metricsOf(t, c); got["bypassed"] != 2 || got["hits"] != 2 {
+		t.Errorf("metrics = %v", got)
+	}
+}
+
+func TestCacheControl(t *testing.T) {
+	for _, tc := range []struct {
+		values           []string
+		noCache, noStore bool
+	}{
+		{nil, false, false},
+		{[]string{"max-age=60"}, false, false},
+		{[]string{"no-cache"}, true, false},
+		{[]string{"No-Cache"}, true, false},
+		{[]string{"no-store"}, true, true},
+		{[]string{"max-age=0, no-store"}, true, true},
+		{[]string{"max-age=0", "no-cache"}, true, false},
+	} {
+		ctx := context.Background()
+		if tc.values != nil {
+			ctx = metadata.NewIncomingContext(ctx, metadata.MD{CacheControlHeader: tc.values})
+		}
+		if noCache, noStore := cacheControl(ctx); noCache != tc.noCache || noStore != tc.noStore {
+			t.Errorf("%q: noCache, noStore = %v, %v; want %v, %v", tc.values, noCache, noStore, tc.noCache, tc.noStore)
+		}
+	}
+}
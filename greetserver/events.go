@@ -0,0 +1,86 @@
+package greetserver
+
+import (
+	"strings"
+	"sync"
+
+	"../greetpb"
+)
+
+// Broker fans GreetingEvents out to SubscribeGreetings streams. It is safe
+// for concurrent use.
+type Broker struct {
+	mu   sync.Mutex
+	subs map[*subscriber]bool
+}
+
+// NewBroker returns a broker with no subscribers.
+func NewBroker() *Broker {
+	return &Broker{subs: make(map[*subscriber]bool)}
+}
+
+// subscriber buffers the events for one stream. dropped is closed when the
+// buffer overflows and the broker gives up on it.
+type subscriber struct {
+	filter  *greetpb.SubscribeGreetingsRequest
+	events  chan *greetpb.GreetingEvent
+	dropped chan struct{}
+}
+
+// matches reports whether one of ev's greetings passes the filter. Without
+// a filter every event matches, even a LongGreet that received no greetings.
+func (s *subscriber) matches(ev *greetpb.GreetingEvent) bool {
+	if s.filter.GetFirstNamePrefix() == "" && s.filter.GetLastNamePrefix() == "" {
+		return true
+	}
+	for _, g := range ev.GetGreetings() {
+		if strings.HasPrefix(g.GetFirstName(), s.filter.GetFirstNamePrefix()) &&
+			strings.HasPrefix(g.GetLastName(), s.filter.GetLastNamePrefix()) {
+			return true
+		}
+	}
+	return false
+}
+
+// Subscribers returns the number of active subscriptions.
+func (b *Broker) Subscribers() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subs)
+}
+
+func (b *Broker) subscribe(filter *greetpb.SubscribeGreetingsRequest, buffer int) *subscriber {
+	sub := &subscriber{
+		filter:  filter,
+		events:  make(chan *greetpb.GreetingEvent, buffer),
+		dropped: make(chan struct{}),
+	}
+	b.mu.Lock()
+	b.subs[sub] = true
+	b.mu.Unlock()
+	return sub
+}
+
+func (b *Broker) unsubscribe(sub *subscriber) {
+	b.mu.Lock()
+	delete(b.subs, sub)
+	b.mu.Unlock()
+}
+
+// publish hands ev to every matching subscriber without blocking. A
+// subscriber whose buffer is full is dropped.
+func (b *Broker) publish(ev *greetpb.GreetingEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for sub := range b.subs {
+		if !sub.matches(ev) {
+			continue
+		}
+		select {
+		case sub.events <- ev:
+		default:
+			delete(b.subs, sub)
+			close(sub.dropped)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package greetserver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"../greetpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestSubscriberMatches(t *testing.T) {
+	nandaR := &greetpb.Greeting{FirstName: "Nanda", LastName: "R"}
+	kumar := &greetpb.Greeting{FirstName: "Kumar"}
+	for _, tc := range []struct {
+		name      string
+		filter    *greetpb.SubscribeGreetingsRequest
+		greetings []*greetpb.Greeting
+		want      bool
+	}{
+		{"no filter", &greetpb.SubscribeGreetingsRequest{}, []*greetpb.Greeting{kumar}, true},
+		{"no filter, no greetings", &greetpb.SubscribeGreetingsRequest{}, nil, true},
+		{"nil filter, no greetings", nil, nil, true},
+		{"first name", &greetpb.SubscribeGreetingsRequest{FirstNamePrefix: "Nan"}, []*greetpb.Greeting{nandaR}, true},
+		{"last name", &greetpb.SubscribeGreetingsRequest{LastNamePrefix: "R"}, []*greetpb.Greeting{nandaR}, true},
+		{"both names", &greetpb.SubscribeGreetingsRequest{FirstNamePrefix: "N", LastNamePrefix: "R"}, []*greetpb.Greeting{nandaR}, true},
+		{"one name differs", &greetpb.SubscribeGreetingsRequest{FirstNamePrefix: "N", LastNamePrefix: "S"}, []*greetpb.Greeting{nandaR}, false},
+		{"case matters", &greetpb.SubscribeGreetingsRequest{FirstNamePrefix: "nan"}, []*greetpb.Greeting{nandaR}, false},
+		{"any greeting", &greetpb.SubscribeGreetingsRequest{FirstNamePrefix: "Kum"}, []*greetpb.Greeting{nandaR, kumar}, true},
+		{"filter, no greetings", &greetpb.SubscribeGreetingsRequest{FirstNamePrefix: "Nan"}, nil, false},
+	} {
+		sub := &subscriber{filter: tc.filter}
+		if got := sub.matches(&greetpb.GreetingEvent{Greetings: tc.greetings}); got != tc.want {
+			t.Errorf("%s: matches = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func waitSubscribers(t *testing.T, b *Broker, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for b.Subscribers() != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d subscribers, want %d", b.Subscribers(), n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// Every LongGreet completes with an event, even one that got no greetings.
+func TestSubscribeEmptyLongGreet(t *testing.T) {
+	b := NewBroker()
+	client := everyOneClient(t, &Server{Events: b})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	sub, err := client.SubscribeGreetings(ctx, &greetpb.SubscribeGreetingsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitSubscribers(t, b, 1)
+
+	long, err := client.LongGreet(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := long.CloseAndRecv(); err != nil {
+		t.Fatal(err)
+	}
+	ev, err := sub.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ev.GetRpc() != greetpb.RpcType_RPC_TYPE_LONG_GREET || len(ev.GetGreetings()) != 0 {
+		t.Errorf("event = %v, want an empty LongGreet", ev)
+	}
+}
+
+// stalledSubscription is a SubscribeGreetings stream whose client stops
+// reading: Send reports each event on sent, then waits for release.
+type stalledSubscription struct {
+	grpc.ServerStream
+	ctx     context.Context
+	sent    chan *greetpb.GreetingEvent
+	release chan struct{}
+}
+
+func (s *stalledSubscription) Context() context.Context { return s.ctx }
+
+func (s *stalledSubscription) Send(ev *greetpb.GreetingEvent) error {
+	select {
+	case s.sent <- ev:
+	default:
+	}
+	<-s.release
+	return nil
+}
+
+func TestSubscribeSlowSubscriberDropped(t *testing.T) {
+	s := &Server{SubscriberBuffer: 2}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	stream := &stalledSubscription{ctx: ctx, sent: make(chan *greetpb.GreetingEvent, 1), release: make(chan struct{})}
+	done := make(chan error, 1)
+	go func() { done <- s.SubscribeGreetings(&greetpb.SubscribeGreetingsRequest{}, stream) }()
+	waitSubscribers(t, s.events(), 1)
+
+	req := &greetpb.GreetRequest{Greeting: &greetpb.Greeting{FirstName: "Nanda"}}
+	s.Greet(ctx, req)
+	<-stream.sent // The stream is now stuck sending the first event.
+	for i := 0; i < 2; i++ {
+		s.Greet(ctx, req)
+	}
+	select {
+	case err := <-done:
+		t.Fatalf("subscription ended with %v while its buffer still had room", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// One more than the buffer holds gives up on the subscriber, and the
+	// greeting itself is not held up.
+	if _, err := s.Greet(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-done:
+		t.Fatalf("subscription ended with %v before its Send returned", err)
+	default:
+	}
+	if n := s.events().Subscribers(); n != 0 {
+		t.Errorf("%d subscribers after the overflow, want 0", n)
+	}
+	close(stream.release)
+	if err := <-done; status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("subscription ended with %v, want ResourceExhausted", err)
+	}
+}
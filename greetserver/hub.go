@@ -0,0 +1,96 @@
+package greetserver
+
+import (
+	"sync"
+
+	"../greetpb"
+)
+
+// Hub tracks which GreetEveryOne streams are in which room and relays
+// greetings and presence events between them. It is safe for concurrent use.
+type Hub struct {
+	mu    sync.Mutex
+	rooms map[string]map[*member]bool
+}
+
+// NewHub returns a hub with no rooms.
+func NewHub() *Hub {
+	return &Hub{rooms: make(map[string]map[*member]bool)}
+}
+
+// member is one GreetEveryOne stream. Relayed responses are offered to its
+// queue, so delivery never blocks the sender.
+type member struct {
+	q    *responseQueue
+	room string
+	from *greetpb.Greeting
+}
+
+// Rooms returns the number of members in each room.
+func (h *Hub) Rooms() map[string]int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	rooms := make(map[string]int, len(h.rooms))
+	for name, members := range h.rooms {
+		rooms[name] = len(members)
+	}
+	return rooms
+}
+
+// greet handles a request from m: it moves m to the request's room if that
+// changed, then relays the greeting to the other members.
+func (h *Hub) greet(m *member, req *greetpb.GreetRequest, result string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if req.GetRoom() != m.room {
+		h.leaveLocked(m)
+		m.room = req.GetRoom()
+		m.from = req.GetGreeting()
+		if m.room == "" {
+			return
+		}
+		if h.rooms[m.room] == nil {
+			h.rooms[m.room] = make(map[*member]bool)
+		}
+		h.rooms[m.room][m] = true
+		h.relayLocked(m, &greetpb.GreetResponse{Presence: greetpb.Presence_PRESENCE_JOINED})
+	}
+	if m.room == "" {
+		return
+	}
+	m.from = req.GetGreeting()
+	h.relayLocked(m, &greetpb.GreetResponse{Result: result})
+}
+
+// leave removes m from its room, announcing it to the rest.
+func (h *Hub) leave(m *member) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.leaveLocked(m)
+}
+
+func (h *Hub) leaveLocked(m *member) {
+	members := h.rooms[m.room]
+	if !members[m] {
+		return
+	}
+	delete(members, m)
+	if len(members) == 0 {
+		delete(h.rooms, m.room)
+	} else {
+		h.relayLocked(m, &greetpb.GreetResponse{Presence: greetpb.Presence_PRESENCE_LEFT})
+	}
+	m.room = ""
+}
+
+// relayLocked sends res, stamped with m's room and greeting, to every other
+// member of the room.
+func (h *Hub) relayLocked(m *member, res *greetpb.GreetResponse) {
+	res.Room = m.room
+	res.From = m.from
+	for other := range h.rooms[m.room] {
+		if other != m {
+			other.q.offer(res)
+		}
+	}
+}
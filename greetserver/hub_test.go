@@ -0,0 +1,193 @@
+package greetserver
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"../greetpb"
+)
+
+func newMember() *member {
+	return &member{q: newResponseQueue(16, QueueBlock, nil)}
+}
+
+// describe renders a relayed response as "<from> <event> <room>".
+func describe(res *greetpb.GreetResponse) string {
+	event := res.GetResult()
+	switch res.GetPresence() {
+	case greetpb.Presence_PRESENCE_JOINED:
+		event = "joined"
+	case greetpb.Presence_PRESENCE_LEFT:
+		event = "left"
+	}
+	return fmt.Sprintf("%s %s %s", res.GetFrom().GetFirstName(), event, res.GetRoom())
+}
+
+// received takes what has been relayed to m so far.
+func received(m *member) []string {
+	m.q.mu.Lock()
+	defer m.q.mu.Unlock()
+	var got []string
+	for _, res := range m.q.items {
+		got = append(got, describe(res))
+	}
+	m.q.items = nil
+	return got
+}
+
+func greetIn(h *Hub, m *member, name, room string) {
+	h.greet(m, &greetpb.GreetRequest{Greeting: &greetpb.Greeting{FirstName: name}, Room: room}, "hi")
+}
+
+func expectReceived(t *testing.T, name string, m *member, want ...string) {
+	t.Helper()
+	if got := received(m); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("%s received %q, want %q", name, got, want)
+	}
+}
+
+func TestHubRelaysToOtherMembers(t *testing.T) {
+	h := NewHub()
+	alice, bob, carol, dave := newMember(), newMember(), newMember(), newMember()
+	greetIn(h, alice, "Alice", "r1")
+	greetIn(h, bob, "Bob", "r1")
+	greetIn(h, carol, "Carol", "r2")
+	greetIn(h, dave, "Dave", "")
+	expectReceived(t, "Alice", alice, "Bob joined r1", "Bob hi r1")
+	expectReceived(t, "Bob", bob)
+	expectReceived(t, "Carol", carol)
+
+	greetIn(h, alice, "Alice", "r1")
+	expectReceived(t, "Alice", alice)
+	expectReceived(t, "Bob", bob, "Alice hi r1")
+	expectReceived(t, "Carol", carol)
+	expectReceived(t, "Dave", dave)
+
+	if got := fmt.Sprint(h.Rooms()); got != "map[r1:2 r2:1]" {
+		t.Errorf("Rooms = %s", got)
+	}
+}
+
+func TestHubSwitchingRooms(t *testing.T) {
+	h := NewHub()
+	alice, bob, carol := newMember(), newMember(), newMember()
+	greetIn(h, alice, "Alice", "r1")
+	greetIn(h, bob, "Bob", "r1")
+	greetIn(h, carol, "Carol", "r2")
+	received(alice)
+
+	greetIn(h, bob, "Bob", "r2")
+	expectReceived(t, "Alice", alice, "Bob left r1")
+	expectReceived(t, "Carol", carol, "Bob joined r2", "Bob hi r2")
+
+	// An empty room leaves the current one without joining another.
+	greetIn(h, bob, "Bob", "")
+	expectReceived(t, "Carol", carol, "Bob left r2")
+	greetIn(h, alice, "Alice", "r1")
+	expectReceived(t, "Bob", bob)
+
+	// Moving the last member out removes the room.
+	greetIn(h, alice, "Alice", "r3")
+	if got := fmt.Sprint(h.Rooms()); got != "map[r2:1 r3:1]" {
+		t.Errorf("Rooms = %s", got)
+	}
+}
+
+func TestHubLeave(t *testing.T) {
+	h := NewHub()
+	alice, bob, never := newMember(), newMember(), newMember()
+	greetIn(h, alice, "Alice", "r1")
+	greetIn(h, bob, "Bob", "r1")
+	received(alice)
+
+	h.leave(bob)
+	expectReceived(t, "Alice", alice, "Bob left r1")
+	h.leave(bob)
+	h.leave(never)
+	expectReceived(t, "Alice", alice)
+
+	h.leave(alice)
+	if rooms := h.Rooms(); len(rooms) != 0 {
+		t.Errorf("Rooms = %v after everybody left", rooms)
+	}
+}
+
+// joinRoom opens a GreetEveryOne stream, greets in room and reads the
+// acknowledgement.
+func joinRoom(t *testing.T, ctx context.Context, client greetpb.GreetServiceClient, name, room string) greetpb.GreetService_GreetEveryOneClient {
+	t.Helper()
+	stream, err := client.GreetEveryOne(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := stream.Send(&greetpb.GreetRequest{Greeting: &greetpb.Greeting{FirstName: name}, Room: room, SequenceId: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if res, err := stream.Recv(); err != nil || res.GetAckSequenceId() != 1 {
+		t.Fatalf("%s: ack = %v, %v", name, res, err)
+	}
+	return stream
+}
+
+func recvRelayed(t *testing.T, name string, stream greetpb.GreetService_GreetEveryOneClient, want string) {
+	t.Helper()
+	res, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	if got := describe(res); got != want {
+		t.Errorf("%s received %q, want %q", name, got, want)
+	}
+}
+
+func waitRooms(t *testing.T, h *Hub, want string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for got := fmt.Sprint(h.Rooms()); got != want; got = fmt.Sprint(h.Rooms()) {
+		if time.Now().After(deadline) {
+			t.Fatalf("Rooms = %s, want %s", got, want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// A stream leaves its room when it ends, whether the client closes it or
+// goes away.
+func TestGreetEveryOneRoomCleanup(t *testing.T) {
+	h := NewHub()
+	client := everyOneClient(t, &Server{Hub: h})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	aliceCtx, aliceGone := context.WithCancel(ctx)
+	alice := joinRoom(t, aliceCtx, client, "Alice", "r1")
+	bob := joinRoom(t, ctx, client, "Bob", "r1")
+	carol := joinRoom(t, ctx, client, "Carol", "r1")
+	recvRelayed(t, "Alice", alice, "Bob joined r1")
+	recvRelayed(t, "Alice", alice, "Bob Hello Bob!  r1")
+	recvRelayed(t, "Bob", bob, "Carol joined r1")
+	recvRelayed(t, "Bob", bob, "Carol Hello Carol!  r1")
+	waitRooms(t, h, "map[r1:3]")
+
+	aliceGone()
+	recvRelayed(t, "Bob", bob, "Alice left r1")
+	recvRelayed(t, "Carol", carol, "Alice left r1")
+	waitRooms(t, h, "map[r1:2]")
+
+	if err := bob.CloseSend(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := bob.Recv(); err != io.EOF {
+		t.Fatalf("Bob: %v, want the end of the stream", err)
+	}
+	recvRelayed(t, "Carol", carol, "Bob left r1")
+
+	carol.CloseSend()
+	if _, err := carol.Recv(); err != io.EOF {
+		t.Fatalf("Carol: %v, want the end of the stream", err)
+	}
+	waitRooms(t, h, "map[]")
+}
@@ -0,0 +1,97 @@
+package greetserver
+
+import (
+	"context"
+	"crypto/sha256"
+	"sync"
+	"time"
+
+	"../greetpb"
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// IdempotencyKeyHeader is the metadata key clients set to make Greet safe to
+// retry. Calls with the same key get the response of the first one.
+const IdempotencyKeyHeader = "idempotency-key"
+
+// IdempotencyCache remembers Greet responses by idempotency key. It is safe
+// for concurrent use.
+type IdempotencyCache struct {
+	mu  sync.Mutex
+	lru *lru
+}
+
+// NewIdempotencyCache keeps the responses of up to size keys, each for ttl.
+func NewIdempotencyCache(size int, ttl time.Duration) *IdempotencyCache {
+	return &IdempotencyCache{lru: newLRU(size, ttl)}
+}
+
+// idempotentCall is the first call made with a key. done is closed once
+// res and err are set.
+type idempotentCall struct {
+	sum  [sha256.Size]byte
+	done chan struct{}
+	res  *greetpb.GreetResponse
+	err  error
+}
+
+// do runs greet once per key. A replay waits for the first call if it is
+// still running and returns a copy of its response. Reusing a key for a
+// different request fails with ALREADY_EXISTS. Failed calls are forgotten
+// so the client can retry them.
+func (c *IdempotencyCache) do(ctx context.Context, key string, req *greetpb.GreetRequest, greet func() (*greetpb.GreetResponse, error)) (*greetpb.GreetResponse, error) {
+	b, err := proto.Marshal(req)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "hashing request : %v", err)
+	}
+	sum := sha256.Sum256(b)
+
+	c.mu.Lock()
+	if v, ok := c.lru.get(key); ok {
+		c.mu.Unlock()
+		call := v.(*idempotentCall)
+		if call.sum != sum {
+			return nil, status.Errorf(codes.AlreadyExists, "idempotency key %q was already used for a different request", key)
+		}
+		select {
+		case <-call.done:
+		case <-ctx.Done():
+			return nil, status.FromContextError(ctx.Err()).Err()
+		}
+		if call.err != nil {
+			return nil, call.err
+		}
+		return proto.Clone(call.res).(*greetpb.GreetResponse), nil
+	}
+	call := &idempotentCall{sum: sum, done: make(chan struct{})}
+	c.lru.add(key, call)
+	c.mu.Unlock()
+
+	res, err := greet()
+	if err != nil {
+		c.mu.Lock()
+		if v, ok := c.lru.get(key); ok && v == call {
+			c.lru.remove(key)
+		}
+		c.mu.Unlock()
+		call.err = err
+	} else {
+		// Replays get copies of a copy, whatever the first caller does
+		// with res.
+		call.res = proto.Clone(res).(*greetpb.GreetResponse)
+	}
+	close(call.done)
+	return res, err
+}
+
+// idempotencyKey returns the key sent by the client, if any.
+func idempotencyKey(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if v := md.Get(IdempotencyKeyHeader); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
@@ -0,0 +1,153 @@
+package greetserver
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"../greetpb"
+	"../greetstore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func withIdempotencyKey(key string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs(IdempotencyKeyHeader, key))
+}
+
+func nandaRequest() *greetpb.GreetRequest {
+	return &greetpb.GreetRequest{Greeting: &greetpb.Greeting{FirstName: "Nanda", LastName: "R"}}
+}
+
+func TestGreetReplayIsNotCounted(t *testing.T) {
+	s := &Server{Counter: greetstore.NewMemoryCounter(), Idempotency: NewIdempotencyCache(10, time.Minute)}
+	ctx := withIdempotencyKey("k1")
+	first, err := s.Greet(ctx, nandaRequest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	first.Result = "changed by the caller"
+	replay, err := s.Greet(ctx, nandaRequest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replay.GetResult() != "Hi Nanda R" || replay.GetGreetCount() != 1 {
+		t.Errorf("replay = %v, want the first response with greet_count 1", replay)
+	}
+	next, err := s.Greet(context.Background(), nandaRequest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next.GetGreetCount() != 2 {
+		t.Errorf("greet_count after a replay = %d, want 2", next.GetGreetCount())
+	}
+}
+
+func TestIdempotencyKeyReusedForAnotherRequest(t *testing.T) {
+	s := &Server{Idempotency: NewIdempotencyCache(10, time.Minute)}
+	ctx := withIdempotencyKey("k1")
+	if _, err := s.Greet(ctx, nandaRequest()); err != nil {
+		t.Fatal(err)
+	}
+	other := &greetpb.GreetRequest{Greeting: &greetpb.Greeting{FirstName: "Kumar"}}
+	if _, err := s.Greet(ctx, other); status.Code(err) != codes.AlreadyExists {
+		t.Fatalf("other request with the same key: %v, want AlreadyExists", err)
+	}
+	if _, err := s.Greet(withIdempotencyKey("k2"), other); err != nil {
+		t.Errorf("other request with its own key: %v", err)
+	}
+}
+
+func TestIdempotentReplayWaitsForFirstCall(t *testing.T) {
+	c := NewIdempotencyCache(10, time.Minute)
+	started, release := make(chan struct{}), make(chan struct{})
+	first := make(chan *greetpb.GreetResponse, 1)
+	go func() {
+		res, _ := c.do(context.Background(), "k1", nandaRequest(), func() (*greetpb.GreetResponse, error) {
+			close(started)
+			<-release
+			return &greetpb.GreetResponse{Result: "Hi Nanda R", GreetCount: 1}, nil
+		})
+		first <- res
+	}()
+	<-started
+
+	type result struct {
+		res *greetpb.GreetResponse
+		err error
+	}
+	replay := make(chan result, 1)
+	go func() {
+		res, err := c.do(context.Background(), "k1", nandaRequest(), func() (*greetpb.GreetResponse, error) {
+			t.Error("replay ran greet again")
+			return nil, nil
+		})
+		replay <- result{res, err}
+	}()
+	select {
+	case r := <-replay:
+		t.Fatalf("replay returned %v, %v before the first call finished", r.res, r.err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// A replay that gives up while waiting fails with its context's error.
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := c.do(ctx, "k1", nandaRequest(), nil); status.Code(err) != codes.DeadlineExceeded {
+		t.Errorf("replay past its deadline: %v, want DeadlineExceeded", err)
+	}
+
+	close(release)
+	r := <-replay
+	if r.err != nil || r.res.GetGreetCount() != 1 {
+		t.Errorf("replay = %v, %v; want the first response", r.res, r.err)
+	}
+	if res := <-first; res == r.res {
+		t.Error("replay shares the first call's response instead of a copy")
+	}
+}
+
+func TestIdempotencyForgetsFailedCalls(t *testing.T) {
+	c := NewIdempotencyCache(10, time.Minute)
+	calls := 0
+	greet := func(err error) func() (*greetpb.GreetResponse, error) {
+		return func() (*greetpb.GreetResponse, error) {
+			calls++
+			if err != nil {
+				return nil, err
+			}
+			return &greetpb.GreetResponse{Result: "Hi Nanda R"}, nil
+		}
+	}
+	failure := status.Error(codes.Unavailable, "try again")
+	if _, err := c.do(context.Background(), "k1", nandaRequest(), greet(failure)); !errors.Is(err, failure) {
+		t.Fatalf("first call: %v, want %v", err, failure)
+	}
+	if _, err := c.do(context.Background(), "k1", nandaRequest(), greet(nil)); err != nil {
+		t.Fatalf("retry after a failure: %v", err)
+	}
+	if _, err := c.do(context.Background(), "k1", nandaRequest(), greet(nil)); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 {
+		t.Errorf("greet ran %d times, want 2: the failure and the retry", calls)
+	}
+}
+
+func TestIdempotencyKeyExpires(t *testing.T) {
+	c := NewIdempotencyCache(10, 20*time.Millisecond)
+	calls := 0
+	greet := func() (*greetpb.GreetResponse, error) {
+		calls++
+		return &greetpb.GreetResponse{}, nil
+	}
+	c.do(context.Background(), "k1", nandaRequest(), greet)
+	c.do(context.Background(), "k1", nandaRequest(), greet)
+	time.Sleep(40 * time.Millisecond)
+	c.do(context.Background(), "k1", nandaRequest(), greet)
+	if calls != 2 {
+		t.Errorf("greet ran %d times, want 2: once, then again after the key expired", calls)
+	}
+}
@@ -0,0 +1,63 @@
+package greetserver
+
+import (
+	"container/list"
+	"time"
+)
+
+// lru is a size-bounded cache whose entries also expire ttl after they were
+// added. It is not safe for concurrent use.
+type lru struct {
+	max   int
+	ttl   time.Duration
+	ll    *list.List
+	items map[string]*list.Element
+}
+
+type lruEntry struct {
+	key     string
+	value   interface{}
+	expires time.Time
+}
+
+func newLRU(max int, ttl time.Duration) *lru {
+	return &lru{max: max, ttl: ttl, ll: list.New(), items: make(map[string]*list.Element)}
+}
+
+func (l *lru) get(key string) (interface{}, bool) {
+	el, ok := l.items[key]
+	if !ok {
+		return nil, false
+	}
+	e := el.Value.(*lruEntry)
+	if time.Now().After(e.expires) {
+		l.ll.Remove(el)
+		delete(l.items, key)
+		return nil, false
+	}
+	l.ll.MoveToFront(el)
+	return e.value, true
+}
+
+func (l *lru) add(key string, value interface{}) {
+	if el, ok := l.items[key]; ok {
+		l.ll.Remove(el)
+	}
+	l.items[key] = l.ll.PushFront(&lruEntry{key: key, value: value, expires: time.Now().Add(l.ttl)})
+	for l.ll.Len() > l.max {
+		oldest := l.ll.Back()
+		l.ll.Remove(oldest)
+		delete(l.items, oldest.Value.(*lruEntry).key)
+	}
+}
+
+func (l *lru) remove(key string) {
+	if el, ok := l.items[key]; ok {
+		l.ll.Remove(el)
+		delete(l.items, key)
+	}
+}
+
+func (l *lru) len() int {
+	return l.ll.Len()
+}
@@ -0,0 +1,201 @@
+package greetserver
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+
+	"../greetpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// QueuePolicy decides what GreetEveryOne does with a new response when the
+// client reads more slowly than it sends and the stream's queue is full.
+type QueuePolicy string
+
+const (
+	// QueueBlock stops reading requests until the client catches up, which
+	// pushes back on the client through HTTP/2 flow control.
+	QueueBlock QueuePolicy = "block"
+	// QueueDropOldest discards the oldest queued response.
+	QueueDropOldest QueuePolicy = "drop-oldest"
+	// QueueError ends the stream with RESOURCE_EXHAUSTED.
+	QueueError QueuePolicy = "error"
+)
+
+// Valid reports whether p is one of the known policies.
+func (p QueuePolicy) Valid() bool {
+	switch p {
+	case QueueBlock, QueueDropOldest, QueueError:
+		return true
+	}
+	return false
+}
+
+// StreamMetrics counts GreetEveryOne queue activity across all streams. It
+// implements expvar.Var, so it can be published as is. A nil *StreamMetrics
+// records nothing.
+type StreamMetrics struct {
+	queueDepth    int64
+	maxQueueDepth int64
+	dropped       int64
+	rejected      int64
+}
+
+func (m *StreamMetrics) queued(delta, depth int) {
+	if m == nil {
+		return
+	}
+	atomic.AddInt64(&m.queueDepth, int64(delta))
+	for {
+		max := atomic.LoadInt64(&m.maxQueueDepth)
+		if int64(depth) <= max || atomic.CompareAndSwapInt64(&m.maxQueueDepth, max, int64(depth)) {
+			return
+		}
+	}
+}
+
+func (m *StreamMetrics) drop() {
+	if m != nil {
+		atomic.AddInt64(&m.dropped, 1)
+	}
+}
+
+func (m *StreamMetrics) reject() {
+	if m != nil {
+		atomic.AddInt64(&m.rejected, 1)
+	}
+}
+
+// String returns the metrics as a JSON object.
+func (m *StreamMetrics) String() string {
+	b, _ := json.Marshal(map[string]int64{
+		"queue_depth":     atomic.LoadInt64(&m.queueDepth),
+		"max_queue_depth": atomic.LoadInt64(&m.maxQueueDepth),
+		"dropped":         atomic.LoadInt64(&m.dropped),
+		"rejected":        atomic.LoadInt64(&m.rejected),
+	})
+	return string(b)
+}
+
+// responseQueue is the bounded queue between the receiving and sending
+// halves of one GreetEveryOne stream.
+type responseQueue struct {
+	size    int
+	policy  QueuePolicy
+	metrics *StreamMetrics
+
+	mu       sync.Mutex
+	items    []*greetpb.GreetResponse
+	closed   bool
+	notEmpty chan struct{}
+	notFull  chan struct{}
+}
+
+func newResponseQueue(size int, policy QueuePolicy, metrics *StreamMetrics) *responseQueue {
+	return &responseQueue{
+		size:     size,
+		policy:   policy,
+		metrics:  metrics,
+		notEmpty: make(chan struct{}, 1),
+		notFull:  make(chan struct{}, 1),
+	}
+}
+
+func signal(c chan struct{}) {
+	select {
+	case c <- struct{}{}:
+	default:
+	}
+}
+
+// push adds res, applying the policy when the queue is full.
+func (q *responseQueue) push(ctx context.Context, res *greetpb.GreetResponse) error {
+	for {
+		q.mu.Lock()
+		if len(q.items) < q.size {
+			q.items = append(q.items, res)
+			q.metrics.queued(1, len(q.items))
+			q.mu.Unlock()
+			signal(q.notEmpty)
+			return nil
+		}
+		switch q.policy {
+		case QueueDropOldest:
+			q.items = append(q.items[1:], res)
+			q.mu.Unlock()
+			q.metrics.drop()
+			return nil
+		case QueueError:
+			q.mu.Unlock()
+			q.metrics.reject()
+			return status.Errorf(codes.ResourceExhausted, "client is not reading responses; %d are queued", q.size)
+		}
+		q.mu.Unlock()
+		select {
+		case <-q.notFull:
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		}
+	}
+}
+
+// offer adds res without blocking. When the queue is full the oldest
+// response is dropped, whatever the policy, so that a slow member of a room
+// cannot hold up everybody else.
+func (q *responseQueue) offer(res *greetpb.GreetResponse) {
+	q.mu.Lock()
+	if len(q.items) < q.size {
+		q.items = append(q.items, res)
+		q.metrics.queued(1, len(q.items))
+	} else {
+		q.items = append(q.items[1:], res)
+		q.metrics.drop()
+	}
+	q.mu.Unlock()
+	signal(q.notEmpty)
+}
+
+// pop waits for the next response. It returns false once the queue is
+// closed and empty, or when ctx is done.
+func (q *responseQueue) pop(ctx context.Context) (*greetpb.GreetResponse, bool) {
+	for {
+		q.mu.Lock()
+		if len(q.items) > 0 {
+			res := q.items[0]
+			q.items = q.items[1:]
+			q.metrics.queued(-1, 0)
+			q.mu.Unlock()
+			signal(q.notFull)
+			return res, true
+		}
+		closed := q.closed
+		q.mu.Unlock()
+		if closed {
+			return nil, false
+		}
+		select {
+		case <-q.notEmpty:
+		case <-ctx.Done():
+			return nil, false
+		}
+	}
+}
+
+// close marks the end of the responses; pop drains what is left first.
+func (q *responseQueue) close() {
+	q.mu.Lock()
+	q.closed = true
+	q.mu.Unlock()
+	signal(q.notEmpty)
+}
+
+// discard drops whatever is still queued when the stream ends early.
+func (q *responseQueue) discard() {
+	q.mu.Lock()
+	q.metrics.queued(-len(q.items), 0)
+	q.items = nil
+	q.mu.Unlock()
+}
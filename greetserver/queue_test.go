@@ -0,0 +1,278 @@
+package greetserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"../greetpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func response(i int) *greetpb.GreetResponse {
+	return &greetpb.GreetResponse{AckSequenceId: uint64(i)}
+}
+
+// popAll closes q and returns the ack ids left in it.
+func popAll(q *responseQueue) []uint64 {
+	q.close()
+	var acks []uint64
+	for {
+		res, ok := q.pop(context.Background())
+		if !ok {
+			return acks
+		}
+		acks = append(acks, res.GetAckSequenceId())
+	}
+}
+
+func metricsOf(t *testing.T, m fmt.Stringer) map[string]int64 {
+	t.Helper()
+	var out map[string]int64
+	if err := json.Unmarshal([]byte(m.String()), &out); err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestQueueBlock(t *testing.T) {
+	m := &StreamMetrics{}
+	q := newResponseQueue(2, QueueBlock, m)
+	for i := 1; i <= 2; i++ {
+		if err := q.push(context.Background(), response(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pushed := make(chan error, 1)
+	go func() { pushed <- q.push(context.Background(), response(3)) }()
+	select {
+	case err := <-pushed:
+		t.Fatalf("push into a full queue returned %v, want it to wait", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// The slow consumer takes one; the producer gets going again.
+	if res, _ := q.pop(context.Background()); res.GetAckSequenceId() != 1 {
+		t.Fatalf("popped %d, want 1", res.GetAckSequenceId())
+	}
+	select {
+	case err := <-pushed:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("push still blocked after a pop")
+	}
+	if got := fmt.Sprint(popAll(q)); got != "[2 3]" {
+		t.Errorf("left in queue %s, want [2 3]", got)
+	}
+	if got := metricsOf(t, m); got["max_queue_depth"] != 2 || got["dropped"] != 0 || got["rejected"] != 0 || got["queue_depth"] != 0 {
+		t.Errorf("metrics = %v", got)
+	}
+}
+
+func TestQueueBlockCancelled(t *testing.T) {
+	q := newResponseQueue(1, QueueBlock, nil)
+	q.push(context.Background(), response(1))
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	if err := q.push(ctx, response(2)); status.Code(err) != codes.Canceled {
+		t.Fatalf("push = %v, want Canceled", err)
+	}
+}
+
+func TestQueueDropOldest(t *testing.T) {
+	m := &StreamMetrics{}
+	q := newResponseQueue(2, QueueDropOldest, m)
+	for i := 1; i <= 5; i++ {
+		if err := q.push(context.Background(), response(i)); err != nil {
+			t.Fatalf("push %d: %v", i, err)
+		}
+	}
+	if got := fmt.Sprint(popAll(q)); got != "[4 5]" {
+		t.Errorf("left in queue %s, want the newest [4 5]", got)
+	}
+	if got := metricsOf(t, m); got["dropped"] != 3 || got["queue_depth"] != 0 {
+		t.Errorf("metrics = %v, want 3 dropped", got)
+	}
+}
+
+func TestQueueError(t *testing.T) {
+	m := &StreamMetrics{}
+	q := newResponseQueue(2, QueueError, m)
+	for i := 1; i <= 2; i++ {
+		if err := q.push(context.Background(), response(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := q.push(context.Background(), response(3)); status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("push = %v, want ResourceExhausted", err)
+	}
+	if got := fmt.Sprint(popAll(q)); got != "[1 2]" {
+		t.Errorf("left in queue %s, want [1 2]", got)
+	}
+	if got := metricsOf(t, m); got["rejected"] != 1 {
+		t.Errorf("metrics = %v, want 1 rejected", got)
+	}
+}
+
+// offer never waits, whatever the policy.
+func TestQueueOffer(t *testing.T) {
+	for _, policy := range []QueuePolicy{QueueBlock, QueueDropOldest, QueueError} {
+		q := newResponseQueue(2, policy, nil)
+		for i := 1; i <= 3; i++ {
+			q.offer(response(i))
+		}
+		if got := fmt.Sprint(popAll(q)); got != "[2 3]" {
+			t.Errorf("%s: left in queue %s, want [2 3]", policy, got)
+		}
+	}
+}
+
+func TestQueuePopCancelled(t *testing.T) {
+	q := newResponseQueue(2, QueueBlock, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	if _, ok := q.pop(ctx); ok {
+		t.Fatal("pop on an empty queue returned a response")
+	}
+}
+
+// window is the fixed HTTP/2 flow control window of everyOneClient; gRPC
+// would otherwise grow it and absorb megabytes before a Send blocks.
+const window = 64 << 10
+
+// everyOneClient serves s over bufconn with small flow control windows, so
+// a client that stops reading soon stalls the server's sends and fills the
+// GreetEveryOne queue.
+func everyOneClient(t *testing.T, s *Server) greetpb.GreetServiceClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer(grpc.InitialWindowSize(window), grpc.InitialConnWindowSize(window))
+	greetpb.RegisterGreetServiceServer(srv, s)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+	conn, err := grpc.Dial("bufnet", grpc.WithInsecure(),
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithInitialWindowSize(window), grpc.WithInitialConnWindowSize(window))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return greetpb.NewGreetServiceClient(conn)
+}
+
+// slowConsumer sends n large requests on a GreetEveryOne stream without
+// reading any response, then reads them all. It returns the ack ids it got
+// and the error that ended the stream, nil for a clean end.
+func slowConsumer(t *testing.T, client greetpb.GreetServiceClient, n int) ([]uint64, error) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	stream, err := client.GreetEveryOne(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	big := strings.Repeat("x", 16<<10)
+	var sent int64
+	go func() {
+		for i := 1; i <= n; i++ {
+			req := &greetpb.GreetRequest{Greeting: &greetpb.Greeting{FirstName: big}, SequenceId: uint64(i)}
+			if stream.Send(req) != nil {
+				return
+			}
+			atomic.AddInt64(&sent, 1)
+		}
+		stream.CloseSend()
+	}()
+	// Give the server time to fill the queue behind the stalled sender.
+	deadline := time.Now().Add(5 * time.Second)
+	for last := int64(-1); time.Now().Before(deadline); {
+		time.Sleep(50 * time.Millisecond)
+		now := atomic.LoadInt64(&sent)
+		if now == last {
+			break
+		}
+		last = now
+	}
+
+	var acks []uint64
+	for {
+		res, err := stream.Recv()
+		if err == io.EOF {
+			return acks, nil
+		}
+		if err != nil {
+			return acks, err
+		}
+		acks = append(acks, res.GetAckSequenceId())
+	}
+}
+
+func TestGreetEveryOneSlowConsumerBlock(t *testing.T) {
+	m := &StreamMetrics{}
+	const n = 100
+	acks, err := slowConsumer(t, everyOneClient(t, &Server{QueueSize: 4, QueuePolicy: QueueBlock, Metrics: m}), n)
+	if err != nil {
+		t.Fatalf("stream ended with %v", err)
+	}
+	if len(acks) != n {
+		t.Fatalf("got %d responses, want all %d", len(acks), n)
+	}
+	for i, ack := range acks {
+		if ack != uint64(i+1) {
+			t.Fatalf("response %d acknowledges %d", i, ack)
+		}
+	}
+	if got := metricsOf(t, m); got["max_queue_depth"] != 4 || got["dropped"] != 0 || got["rejected"] != 0 {
+		t.Errorf("metrics = %v, want a full queue and nothing lost", got)
+	}
+}
+
+func TestGreetEveryOneSlowConsumerDropOldest(t *testing.T) {
+	m := &StreamMetrics{}
+	const n = 100
+	acks, err := slowConsumer(t, everyOneClient(t, &Server{QueueSize: 4, QueuePolicy: QueueDropOldest, Metrics: m}), n)
+	if err != nil {
+		t.Fatalf("stream ended with %v", err)
+	}
+	if len(acks) == n {
+		t.Fatal("every response arrived; the queue never overflowed")
+	}
+	for i := 1; i < len(acks); i++ {
+		if acks[i] <= acks[i-1] {
+			t.Fatalf("responses out of order: %v", acks)
+		}
+	}
+	if acks[len(acks)-1] != n {
+		t.Errorf("last response acknowledges %d, want the newest, %d", acks[len(acks)-1], n)
+	}
+	if got := metricsOf(t, m); got["dropped"] != int64(n-len(acks)) || got["rejected"] != 0 {
+		t.Errorf("metrics = %v, want %d dropped", got, n-len(acks))
+	}
+}
+
+func TestGreetEveryOneSlowConsumerError(t *testing.T) {
+	m := &StreamMetrics{}
+	acks, err := slowConsumer(t, everyOneClient(t, &Server{QueueSize: 4, QueuePolicy: QueueError, Metrics: m}), 100)
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("stream ended with %v after %d responses, want ResourceExhausted", err, len(acks))
+	}
+	for i, ack := range acks {
+		if ack != uint64(i+1) {
+			t.Fatalf("response %d acknowledges %d", i, ack)
+		}
+	}
+	if got := metricsOf(t, m); got["rejected"] != 1 || got["dropped"] != 0 {
+		t.Errorf("metrics = %v, want 1 rejected", got)
+	}
+}
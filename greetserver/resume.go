@@ -0,0 +1,44 @@
+package greetserver
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash/crc32"
+
+	"../greetpb"
+)
+
+// resumeToken encodes the index of the next GreetManyTimes message together
+// with a checksum of the greeting, so a token cannot be replayed against a
+// stream for somebody else.
+func resumeToken(g *greetpb.Greeting, next int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d.%08x", next, greetingSum(g))))
+}
+
+// parseResumeToken returns the index to continue from; an empty token
+// starts at the beginning.
+func parseResumeToken(g *greetpb.Greeting, token string) (int, error) {
+	if token == "" {
+		return 0, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, errors.New("malformed resume_token")
+	}
+	var next int
+	var sum uint32
+	// Sscanf ignores whatever follows the checksum, so the token must also
+	// be exactly what resumeToken would have produced.
+	if _, err := fmt.Sscanf(string(b), "%d.%08x", &next, &sum); err != nil || next < 0 || fmt.Sprintf("%d.%08x", next, sum) != string(b) {
+		return 0, errors.New("malformed resume_token")
+	}
+	if sum != greetingSum(g) {
+		return 0, errors.New("resume_token belongs to a different greeting")
+	}
+	return next, nil
+}
+
+func greetingSum(g *greetpb.Greeting) uint32 {
+	return crc32.ChecksumIEEE([]byte(g.GetFirstName() + "\x00" + g.GetLastName()))
+}
@@ -0,0 +1,78 @@
+package greetserver
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"../greetpb"
+)
+
+func TestResumeTokenRoundTrip(t *testing.T) {
+	g := &greetpb.Greeting{FirstName: "Nanda", LastName: "R"}
+	for _, next := range []int{0, 1, 9, 10} {
+		got, err := parseResumeToken(g, resumeToken(g, next))
+		if err != nil || got != next {
+			t.Errorf("parseResumeToken(resumeToken(%d)) = %d, %v", next, got, err)
+		}
+	}
+	if got, err := parseResumeToken(g, ""); err != nil || got != 0 {
+		t.Errorf("empty token = %d, %v; want 0, nil", got, err)
+	}
+}
+
+func TestParseResumeTokenMalformed(t *testing.T) {
+	g := &greetpb.Greeting{FirstName: "Nanda", LastName: "R"}
+	valid := resumeToken(g, 3)
+	raw, _ := base64.RawURLEncoding.DecodeString(valid)
+	encode := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+	sum := strings.SplitN(string(raw), ".", 2)[1]
+
+	for name, token := range map[string]string{
+		"not base64":        "not a token!",
+		"padded base64":     base64.URLEncoding.EncodeToString(raw),
+		"no separator":      encode("3" + sum),
+		"no index":          encode("." + sum),
+		"negative index":    encode("-1." + sum),
+		"no checksum":       encode("3."),
+		"short checksum":    encode("3.abc"),
+		"non-hex checksum":  encode("3.zzzzzzzz"),
+		"trailing garbage":  encode(string(raw) + "x"),
+		"trailing dot":      encode(string(raw) + "."),
+		"leading space":     encode(" " + string(raw)),
+		"leading plus sign": encode("+" + string(raw)),
+		"index overflow":    encode(strings.Repeat("9", 30) + "." + sum),
+	} {
+		if next, err := parseResumeToken(g, token); err == nil {
+			t.Errorf("%s: %q parsed as %d, want an error", name, token, next)
+		} else if err.Error() != "malformed resume_token" {
+			t.Errorf("%s: error %q, want malformed resume_token", name, err)
+		}
+	}
+}
+
+func TestParseResumeTokenOtherGreeting(t *testing.T) {
+	g := &greetpb.Greeting{FirstName: "Nanda", LastName: "R"}
+	token := resumeToken(g, 5)
+	for _, other := range []*greetpb.Greeting{
+		{FirstName: "Kumar", LastName: "R"},
+		{FirstName: "Nanda", LastName: "S"},
+		{FirstName: "NandaR"},
+		// The separator keeps first and last name apart.
+		{FirstName: "Nand", LastName: "aR"},
+		nil,
+	} {
+		_, err := parseResumeToken(other, token)
+		if err == nil || err.Error() != "resume_token belongs to a different greeting" {
+			t.Errorf("token for %v used for %v: %v, want a different greeting error", g, other, err)
+		}
+	}
+}
+
+// Tokens travel in URLs through the REST gateway.
+func TestResumeTokenIsURLSafe(t *testing.T) {
+	g := &greetpb.Greeting{FirstName: "Nanda ?&/", LastName: "R+="}
+	if token := resumeToken(g, 7); strings.ContainsAny(token, "+/=?&") {
+		t.Errorf("token %q is not URL safe", token)
+	}
+}
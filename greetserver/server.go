@@ -0,0 +1,379 @@
+package greetserver
+
+import (
+	"context"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"../greetlog"
+	"../greetpb"
+	"../greetstore"
+	"github.com/golang/protobuf/ptypes"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Server implements greetpb.GreetServiceServer.
+type Server struct {
+	// Interval is the delay between messages sent by GreetManyTimes.
+	Interval time.Duration
+	// QueueSize bounds the responses GreetEveryOne holds for a client that
+	// reads slowly; QueuePolicy says what happens when it is full. They
+	// default to 16 and QueueBlock.
+	QueueSize   int
+	QueuePolicy QueuePolicy
+	// Metrics, if set, records GreetEveryOne queue activity.
+	Metrics *StreamMetrics
+	// Hub relays GreetEveryOne greetings between members of a room. When
+	// nil, the Server creates its own on first use.
+	Hub *Hub
+
+	// Events delivers greeting events to SubscribeGreetings streams, each
+	// of which may fall SubscriberBuffer events (default 64) behind before
+	// it is disconnected. When nil, the Server creates its own on first use.
+	Events           *Broker
+	SubscriberBuffer int
+	// Store, if set, records every greeting handled and serves
+	// ListGreetings.
+	Store greetstore.Store
+	// Counter, if set, counts the Greet calls per person for greet_count.
+	Counter greetstore.Counter
+	// Idempotency, if set, makes Greet calls that carry the same
+	// IdempotencyKeyHeader run once: replays get the first response and
+	// are neither counted nor recorded again.
+	Idempotency *IdempotencyCache
+	// MaxBatchSize bounds the greetings of one GreetBatch call. It
+	// defaults to 100.
+	MaxBatchSize int
+
+	hubOnce    sync.Once
+	eventsOnce sync.Once
+}
+
+func (s *Server) hub() *Hub {
+	s.hubOnce.Do(func() {
+		if s.Hub == nil {
+			s.Hub = NewHub()
+		}
+	})
+	return s.Hub
+}
+
+func (s *Server) events() *Broker {
+	s.eventsOnce.Do(func() {
+		if s.Events == nil {
+			s.Events = NewBroker()
+		}
+	})
+	return s.Events
+}
+
+// publish records a handled greeting for subscribers.
+func (s *Server) publish(ctx context.Context, rpc greetpb.RpcType, greetings []*greetpb.Greeting, result string) {
+	ev := &greetpb.GreetingEvent{
+		Rpc:       rpc,
+		Greetings: greetings,
+		Result:    result,
+		Time:      ptypes.TimestampNow(),
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		ev.Peer = p.Addr.String()
+	}
+	s.events().publish(ev)
+}
+
+// record adds greetings to the history. Failing to record is logged but
+// does not fail the RPC.
+func (s *Server) record(ctx context.Context, rpc greetpb.RpcType, greetings ...*greetpb.Greeting) {
+	if s.Store == nil {
+		return
+	}
+	now, who := ptypes.TimestampNow(), caller(ctx)
+	for _, g := range greetings {
+		rec := &greetpb.GreetingRecord{Greeting: g, Rpc: rpc, Time: now, Caller: who}
+		if err := s.Store.Add(ctx, rec); err != nil {
+			greetlog.Errorf("Error while recording greeting : %v", err)
+		}
+	}
+}
+
+// caller identifies the client by its verified TLS certificate, falling
+// back to its address. Calls over an in-process bufconn come from the REST
+// gateway, which appends the HTTP client's address to x-forwarded-for.
+// Everything before that last hop was sent by the client and is ignored.
+func caller(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	if info, ok := p.AuthInfo.(credentials.TLSInfo); ok && len(info.State.VerifiedChains) > 0 {
+		return info.State.VerifiedChains[0][0].Subject.CommonName
+	}
+	if p.Addr.Network() == "bufconn" {
+		md, _ := metadata.FromIncomingContext(ctx)
+		if xff := md.Get("x-forwarded-for"); len(xff) > 0 {
+			hops := strings.Split(xff[len(xff)-1], ",")
+			if last := strings.TrimSpace(hops[len(hops)-1]); last != "" {
+				return last
+			}
+		}
+	}
+	return p.Addr.String()
+}
+
+// Unary
+func (s *Server) Greet(ctx context.Context, req *greetpb.GreetRequest) (*greetpb.GreetResponse, error) {
+	if key := idempotencyKey(ctx); key != "" && s.Idempotency != nil {
+		return s.Idempotency.do(ctx, key, req, func() (*greetpb.GreetResponse, error) {
+			return s.greet(ctx, req)
+		})
+	}
+	return s.greet(ctx, req)
+}
+
+func (s *Server) greet(ctx context.Context, req *greetpb.GreetRequest) (*greetpb.GreetResponse, error) {
+	greetlog.Infof("Greet function invoked  with %v", req)
+	return s.greetOne(ctx, greetpb.RpcType_RPC_TYPE_GREET, req.GetGreeting()), nil
+}
+
+// greetOne greets g, counting, publishing and recording it as sent by rpc.
+func (s *Server) greetOne(ctx context.Context, rpc greetpb.RpcType, g *greetpb.Greeting) *greetpb.GreetResponse {
+	res := &greetpb.GreetResponse{Result: "Hi " + g.GetFirstName() + " " + g.GetLastName()}
+	if key := greetstore.CountKey(g); s.Counter != nil && key != "" {
+		n, err := s.Counter.Increment(ctx, key)
+		if err != nil {
+			greetlog.Errorf("Error while counting greeting : %v", err)
+		}
+		res.GreetCount = n
+	}
+	s.publish(ctx, rpc, []*greetpb.Greeting{g}, res.Result)
+	s.record(ctx, rpc, g)
+	return res
+}
+
+// Server Stream. Every message carries a resume token; a request with one
+// continues after the message it came from.
+func (s *Server) GreetManyTimes(req *greetpb.GreetRequest, stream greetpb.GreetService_GreetManyTimesServer) error {
+	greetlog.Infof("GreetManyTimes function invoked  with %v", req)
+	start, err := parseResumeToken(req.GetGreeting(), req.GetResumeToken())
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	if start == 0 {
+		s.record(stream.Context(), greetpb.RpcType_RPC_TYPE_GREET_MANY_TIMES, req.GetGreeting())
+	}
+	for i := start; i < 10; i++ {
+		res := &greetpb.GreetResponse{
+			Result:      "Hello " + req.GetGreeting().GetFirstName() + " " + req.GetGreeting().GetLastName() + " : number = " + strconv.Itoa(i),
+			ResumeToken: resumeToken(req.GetGreeting(), i+1),
+		}
+		if err := stream.Send(res); err != nil {
+			greetlog.Errorf("Error while calling GreetManyTimes function : %v", err)
+			return err
+		}
+		select {
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case <-time.After(s.Interval):
+		}
+	}
+	return nil
+}
+
+// Client Streaming
+func (s *Server) LongGreet(reqStream greetpb.GreetService_LongGreetServer) error {
+	greetlog.Infof("LongGreet function invoked  with client streaming..!!")
+	result := ""
+	var greetings []*greetpb.Greeting
+	for {
+		req, err := reqStream.Recv()
+		if err == io.EOF {
+			s.publish(reqStream.Context(), greetpb.RpcType_RPC_TYPE_LONG_GREET, greetings, result)
+			s.record(reqStream.Context(), greetpb.RpcType_RPC_TYPE_LONG_GREET, greetings...)
+			return reqStream.SendAndClose(&greetpb.GreetResponse{Result: result})
+		}
+		if err != nil {
+			return err
+		}
+		result += "Hello " + req.GetGreeting().GetFirstName() + "! "
+		greetings = append(greetings, req.GetGreeting())
+	}
+}
+
+// Bi-Directional Streaming. Each response acknowledges the request it
+// answers by echoing its sequence_id. Requests are read and responses sent
+// by separate goroutines joined by a bounded queue, so a slow reader is
+// handled by the queue policy instead of buffering without limit. Requests
+// with a room are also relayed to the other streams in that room.
+func (s *Server) GreetEveryOne(stream greetpb.GreetService_GreetEveryOneServer) error {
+	greetlog.Infof("GreetEveryOne function invoked  with client and server streaming..!!")
+	size, policy := s.QueueSize, s.QueuePolicy
+	if size <= 0 {
+		size = 16
+	}
+	if policy == "" {
+		policy = QueueBlock
+	}
+	q := newResponseQueue(size, policy, s.Metrics)
+	defer q.discard()
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+	hub, m := s.hub(), &member{q: q}
+	defer hub.leave(m)
+
+	sent := make(chan error, 1)
+	go func() {
+		for {
+			res, ok := q.pop(ctx)
+			if !ok {
+				sent <- nil
+				return
+			}
+			if err := stream.Send(res); err != nil {
+				greetlog.Errorf("Error while sending GreetEveryOne response : %v", err)
+				cancel()
+				sent <- err
+				return
+			}
+		}
+	}()
+
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			hub.leave(m)
+			q.close()
+			return <-sent
+		}
+		if err != nil {
+			greetlog.Errorf("Error while calling GreetEveryOne function : %v", err)
+			return err
+		}
+		result := "Hello " + req.GetGreeting().GetFirstName() + "! "
+		res := &greetpb.GreetResponse{Result: result, AckSequenceId: req.GetSequenceId()}
+		if err := q.push(ctx, res); err != nil {
+			select {
+			case err = <-sent:
+			default:
+			}
+			return err
+		}
+		hub.greet(m, req, result)
+		s.publish(ctx, greetpb.RpcType_RPC_TYPE_GREET_EVERY_ONE, []*greetpb.Greeting{req.GetGreeting()}, result)
+		s.record(ctx, greetpb.RpcType_RPC_TYPE_GREET_EVERY_ONE, req.GetGreeting())
+	}
+}
+
+// Server Stream of greeting events. A subscriber that cannot keep up is
+// ended with RESOURCE_EXHAUSTED rather than slowing down the greetings.
+func (s *Server) SubscribeGreetings(req *greetpb.SubscribeGreetingsRequest, stream greetpb.GreetService_SubscribeGreetingsServer) error {
+	greetlog.Infof("SubscribeGreetings function invoked  with %v", req)
+	size := s.SubscriberBuffer
+	if size <= 0 {
+		size = 64
+	}
+	events := s.events()
+	sub := events.subscribe(req, size)
+	defer events.unsubscribe(sub)
+	for {
+		select {
+		case ev := <-sub.events:
+			if err := stream.Send(ev); err != nil {
+				return err
+			}
+		case <-sub.dropped:
+			return status.Errorf(codes.ResourceExhausted, "subscriber fell more than %d events behind", size)
+		case <-stream.Context().Done():
+			return status.FromContextError(stream.Context().Err()).Err()
+		}
+	}
+}
+
+// Unary query of the greeting history
+func (s *Server) ListGreetings(ctx context.Context, req *greetpb.ListGreetingsRequest) (*greetpb.ListGreetingsResponse, error) {
+	greetlog.Infof("ListGreetings function invoked  with %v", req)
+	if s.Store == nil {
+		return nil, status.Error(codes.FailedPrecondition, "greeting history is not enabled")
+	}
+	q := greetstore.Query{PageSize: int(req.GetPageSize()), PageToken: req.GetPageToken()}
+	switch {
+	case q.PageSize < 0:
+		return nil, status.Errorf(codes.InvalidArgument, "page_size must not be negative, got %d", q.PageSize)
+	case q.PageSize == 0:
+		q.PageSize = 50
+	case q.PageSize > 1000:
+		q.PageSize = 1000
+	}
+	var err error
+	if req.GetStartTime() != nil {
+		if q.Start, err = ptypes.Timestamp(req.GetStartTime()); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "start_time : %v", err)
+		}
+	}
+	if req.GetEndTime() != nil {
+		if q.End, err = ptypes.Timestamp(req.GetEndTime()); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "end_time : %v", err)
+		}
+	}
+	recs, next, err := s.Store.List(ctx, q)
+	if err == greetstore.ErrInvalidPageToken {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "listing greetings : %v", err)
+	}
+	return &greetpb.ListGreetingsResponse{Greetings: recs, NextPageToken: next}, nil
+}
+
+// Unary
+func (s *Server) ResetGreetingCount(ctx context.Context, req *greetpb.ResetGreetingCountRequest) (*greetpb.ResetGreetingCountResponse, error) {
+	greetlog.Infof("ResetGreetingCount function invoked  with %v", req)
+	if s.Counter == nil {
+		return nil, status.Error(codes.FailedPrecondition, "greeting counts are not enabled")
+	}
+	key := greetstore.CountKey(req.GetGreeting())
+	if key == "" {
+		return nil, status.Error(codes.InvalidArgument, "greeting must have a name")
+	}
+	n, err := s.Counter.Reset(ctx, key)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "resetting greeting count : %v", err)
+	}
+	return &greetpb.ResetGreetingCountResponse{PreviousCount: n}, nil
+}
+
+// Unary. The greetings are handled concurrently; one without a name fails
+// on its own with INVALID_ARGUMENT instead of failing the whole batch.
+func (s *Server) GreetBatch(ctx context.Context, req *greetpb.GreetBatchRequest) (*greetpb.GreetBatchResponse, error) {
+	greetlog.Infof("GreetBatch function invoked  with %d greetings", len(req.GetGreetings()))
+	max := s.MaxBatchSize
+	if max <= 0 {
+		max = 100
+	}
+	if len(req.GetGreetings()) > max {
+		return nil, status.Errorf(codes.InvalidArgument, "batch has %d greetings, at most %d are allowed", len(req.GetGreetings()), max)
+	}
+
+	results := make([]*greetpb.GreetResult, len(req.GetGreetings()))
+	var wg sync.WaitGroup
+	for i, g := range req.GetGreetings() {
+		if greetstore.CountKey(g) == "" {
+			results[i] = &greetpb.GreetResult{Code: int32(codes.InvalidArgument), Message: "greeting must have a name"}
+			continue
+		}
+		wg.Add(1)
+		go func(i int, g *greetpb.Greeting) {
+			defer wg.Done()
+			res := s.greetOne(ctx, greetpb.RpcType_RPC_TYPE_GREET_BATCH, g)
+			results[i] = &greetpb.GreetResult{Code: int32(codes.OK), Result: res.GetResult(), GreetCount: res.GetGreetCount()}
+		}(i, g)
+	}
+	wg.Wait()
+	return &greetpb.GreetBatchResponse{Results: results}, nil
+}
@@ -0,0 +1,197 @@
+package greetserver
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"../greetpb"
+	"../greetstore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// bufconnAddr is the address of a bufconn connection, as seen by handlers
+// behind the REST gateway.
+type bufconnAddr struct{}
+
+func (bufconnAddr) Network() string { return "bufconn" }
+
+func (bufconnAddr) String() string { return "bufconn" }
+
+func TestCaller(t *testing.T) {
+	tcp := &net.TCPAddr{IP: net.ParseIP("192.0.2.7"), Port: 4242}
+	verified := credentials.TLSInfo{State: tls.ConnectionState{
+		VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: "greet_client"}}}},
+	}}
+	for _, tc := range []struct {
+		name string
+		peer *peer.Peer
+		xff  []string
+		want string
+	}{
+		{"no peer", nil, nil, ""},
+		{"tcp", &peer.Peer{Addr: tcp}, nil, "192.0.2.7:4242"},
+		{"tcp ignores x-forwarded-for", &peer.Peer{Addr: tcp}, []string{"203.0.113.1"}, "192.0.2.7:4242"},
+		{"client certificate", &peer.Peer{Addr: tcp, AuthInfo: verified}, nil, "greet_client"},
+		{"unverified certificate", &peer.Peer{Addr: tcp, AuthInfo: credentials.TLSInfo{}}, nil, "192.0.2.7:4242"},
+		{"gateway", &peer.Peer{Addr: bufconnAddr{}}, []string{"198.51.100.9"}, "198.51.100.9"},
+		{"gateway without x-forwarded-for", &peer.Peer{Addr: bufconnAddr{}}, nil, "bufconn"},
+		// The gateway appends the address it saw to the client's header.
+		{"spoofed hops", &peer.Peer{Addr: bufconnAddr{}}, []string{"203.0.113.1, 203.0.113.2, 198.51.100.9"}, "198.51.100.9"},
+		{"spoofed hops without spaces", &peer.Peer{Addr: bufconnAddr{}}, []string{"203.0.113.1,198.51.100.9"}, "198.51.100.9"},
+		{"several headers", &peer.Peer{Addr: bufconnAddr{}}, []string{"203.0.113.1", "198.51.100.9"}, "198.51.100.9"},
+		{"empty last hop", &peer.Peer{Addr: bufconnAddr{}}, []string{"203.0.113.1, "}, "bufconn"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tc.peer != nil {
+				ctx = peer.NewContext(ctx, tc.peer)
+			}
+			if tc.xff != nil {
+				ctx = metadata.NewIncomingContext(ctx, metadata.MD{"x-forwarded-for": tc.xff})
+			}
+			if got := caller(ctx); got != tc.want {
+				t.Errorf("caller = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+// slowCounter is a Counter that takes a while, and remembers how many
+// increments it saw at once.
+type slowCounter struct {
+	*greetstore.MemoryCounter
+	mu          sync.Mutex
+	inFlight    int
+	maxInFlight int
+}
+
+func (c *slowCounter) Increment(ctx context.Context, key string) (uint64, error) {
+	c.mu.Lock()
+	c.inFlight++
+	if c.inFlight > c.maxInFlight {
+		c.maxInFlight = c.inFlight
+	}
+	c.mu.Unlock()
+	time.Sleep(20 * time.Millisecond)
+	c.mu.Lock()
+	c.inFlight--
+	c.mu.Unlock()
+	return c.MemoryCounter.Increment(ctx, key)
+}
+
+func TestGreetBatch(t *testing.T) {
+	counter := &slowCounter{MemoryCounter: greetstore.NewMemoryCounter()}
+	store := greetstore.NewMemory(100)
+	s := &Server{Counter: counter, Store: store}
+	res, err := s.GreetBatch(context.Background(), &greetpb.GreetBatchRequest{Greetings: []*greetpb.Greeting{
+		{FirstName: "Nanda", LastName: "R"},
+		nil,
+		{},
+		{FirstName: "Kumar"},
+		{FirstName: " ", LastName: "  "},
+		{FirstName: "nanda", LastName: "r"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		code   codes.Code
+		result string
+	}{
+		{codes.OK, "Hi Nanda R"},
+		{codes.InvalidArgument, ""},
+		{codes.InvalidArgument, ""},
+		{codes.OK, "Hi Kumar "},
+		{codes.InvalidArgument, ""},
+		{codes.OK, "Hi nanda r"},
+	}
+	results := res.GetResults()
+	if len(results) != len(want) {
+		t.Fatalf("got %d results, want %d", len(results), len(want))
+	}
+	for i, w := range want {
+		r := results[i]
+		if codes.Code(r.GetCode()) != w.code || r.GetResult() != w.result {
+			t.Errorf("result %d = %v, want %v %q", i, r, w.code, w.result)
+		}
+		if w.code != codes.OK && r.GetMessage() == "" {
+			t.Errorf("result %d has no error message", i)
+		}
+	}
+	// Both spellings of Nanda R count as one person, greeted twice.
+	if a, b := results[0].GetGreetCount(), results[5].GetGreetCount(); a+b != 3 || a == b {
+		t.Errorf("greet counts for Nanda R = %d and %d, want 1 and 2", a, b)
+	}
+	if results[3].GetGreetCount() != 1 {
+		t.Errorf("greet count for Kumar = %d, want 1", results[3].GetGreetCount())
+	}
+	if counter.maxInFlight < 2 {
+		t.Errorf("at most %d greetings were handled at once, want them concurrent", counter.maxInFlight)
+	}
+
+	recs, _, err := store.List(context.Background(), greetstore.Query{PageSize: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recs) != 3 {
+		t.Errorf("%d greetings recorded, want the 3 valid ones", len(recs))
+	}
+	for _, rec := range recs {
+		if rec.GetRpc() != greetpb.RpcType_RPC_TYPE_GREET_BATCH {
+			t.Errorf("recorded as %v, want %v", rec.GetRpc(), greetpb.RpcType_RPC_TYPE_GREET_BATCH)
+		}
+	}
+}
+
+// Results stay in request order however the goroutines are scheduled.
+func TestGreetBatchKeepsOrder(t *testing.T) {
+	var greetings []*greetpb.Greeting
+	for i := 0; i < 100; i++ {
+		greetings = append(greetings, &greetpb.Greeting{FirstName: fmt.Sprint("P", i)})
+	}
+	res, err := (&Server{}).GreetBatch(context.Background(), &greetpb.GreetBatchRequest{Greetings: greetings})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, r := range res.GetResults() {
+		if want := fmt.Sprintf("Hi P%d ", i); r.GetResult() != want {
+			t.Fatalf("result %d = %q, want %q", i, r.GetResult(), want)
+		}
+	}
+}
+
+func TestGreetBatchTooLarge(t *testing.T) {
+	batch := func(n int) *greetpb.GreetBatchRequest {
+		req := &greetpb.GreetBatchRequest{}
+		for i := 0; i < n; i++ {
+			req.Greetings = append(req.Greetings, &greetpb.Greeting{FirstName: "Nanda"})
+		}
+		return req
+	}
+	for _, tc := range []struct {
+		max, n int
+		ok     bool
+	}{
+		{2, 2, true},
+		{2, 3, false},
+		{0, 100, true}, // the default
+		{0, 101, false},
+	} {
+		s := &Server{MaxBatchSize: tc.max}
+		_, err := s.GreetBatch(context.Background(), batch(tc.n))
+		if tc.ok && err != nil || !tc.ok && status.Code(err) != codes.InvalidArgument {
+			t.Errorf("max %d, %d greetings: %v", tc.max, tc.n, err)
+		}
+	}
+}
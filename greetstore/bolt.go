@@ -0,0 +1,164 @@
+package greetstore
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"../greetpb"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	greetingsBucket = []byte("greetings")
+	countsBucket    = []byte("greet_counts")
+)
+
+// Bolt stores greetings and greet counts in a bbolt database file, so they
+// survive restarts. It implements both Store and Counter. Every Add and
+// Increment is a write synced to disk.
+type Bolt struct {
+	db  *bolt.DB
+	max int
+
+	mu      sync.Mutex // held by Add
+	records int
+}
+
+// OpenBolt opens or creates the database at path. Once it holds
+// maxRecords greetings, each new one replaces the oldest; zero keeps them
+// all. It fails if another process holds the file for longer than a second.
+func OpenBolt(path string, maxRecords int) (*Bolt, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{greetingsBucket, countsBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	b := &Bolt{db: db, max: maxRecords}
+	if err == nil {
+		err = db.View(func(tx *bolt.Tx) error {
+			b.records = tx.Bucket(greetingsBucket).Stats().KeyN
+			return nil
+		})
+	}
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return b, nil
+}
+
+func (b *Bolt) Add(ctx context.Context, rec *greetpb.GreetingRecord) error {
+	t, err := ptypes.Timestamp(rec.GetTime())
+	if err != nil {
+		return err
+	}
+	value, err := proto.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	records := b.records
+	err = b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(greetingsBucket)
+		seq, err := bucket.NextSequence()
+		if err != nil {
+			return err
+		}
+		if err := bucket.Put(makeKey(t, seq), value); err != nil {
+			return err
+		}
+		records++
+		// Keys sort in time order, so the first is the oldest.
+		for c := bucket.Cursor(); b.max > 0 && records > b.max; records-- {
+			c.First()
+			if err := c.Delete(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err == nil {
+		b.records = records
+	}
+	return err
+}
+
+func (b *Bolt) List(ctx context.Context, q Query) ([]*greetpb.GreetingRecord, string, error) {
+	from, exclusive, err := q.startKey()
+	if err != nil {
+		return nil, "", err
+	}
+	var recs []*greetpb.GreetingRecord
+	next := ""
+	err = b.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(greetingsBucket).Cursor()
+		var k, v []byte
+		if from == nil {
+			k, v = c.First()
+		} else if k, v = c.Seek(from); k != nil && exclusive && bytes.Equal(k, from) {
+			k, v = c.Next()
+		}
+		var last []byte
+		for ; k != nil && !q.beyond(k); k, v = c.Next() {
+			if len(recs) == q.PageSize {
+				next = encodeToken(last)
+				return nil
+			}
+			rec := &greetpb.GreetingRecord{}
+			if err := proto.Unmarshal(v, rec); err != nil {
+				return err
+			}
+			recs = append(recs, rec)
+			last = append(last[:0], k...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return recs, next, nil
+}
+
+func (b *Bolt) Increment(ctx context.Context, key string) (uint64, error) {
+	var n uint64
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(countsBucket)
+		if v := bucket.Get([]byte(key)); len(v) == 8 {
+			n = binary.BigEndian.Uint64(v)
+		}
+		n++
+		v := make([]byte, 8)
+		binary.BigEndian.PutUint64(v, n)
+		return bucket.Put([]byte(key), v)
+	})
+	return n, err
+}
+
+func (b *Bolt) Reset(ctx context.Context, key string) (uint64, error) {
+	var n uint64
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(countsBucket)
+		if v := bucket.Get([]byte(key)); len(v) == 8 {
+			n = binary.BigEndian.Uint64(v)
+		}
+		return bucket.Delete([]byte(key))
+	})
+	return n, err
+}
+
+func (b *Bolt) Close() error {
+	return b.db.Close()
+}
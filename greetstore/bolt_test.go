@@ -0,0 +1,112 @@
+package greetstore
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+func TestBoltReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "greetings.db")
+	b, err := OpenBolt(path, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := b.Add(context.Background(), record(t, fmt.Sprint("P", i), i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := b.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err = OpenBolt(path, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+	if got := fmt.Sprint(listAll(t, b, Query{PageSize: 10})); got != "[P0 P1 ]" {
+		t.Fatalf("after reopening: pages %s, want [P0 P1 ]", got)
+	}
+	// The records already in the file count towards the limit.
+	for i := 2; i < 4; i++ {
+		if err := b.Add(context.Background(), record(t, fmt.Sprint("P", i), i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := fmt.Sprint(listAll(t, b, Query{PageSize: 10})); got != "[P1 P2 P3 ]" {
+		t.Errorf("pages %s, want the 3 latest greetings", got)
+	}
+}
+
+func TestBoltUnlimited(t *testing.T) {
+	b, err := OpenBolt(filepath.Join(t.TempDir(), "greetings.db"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+	addRecords(t, b)
+	if got := fmt.Sprint(listAll(t, b, Query{PageSize: 100})); got != "[P0 P1 P2 P3 P4 P5 P6 P7 P8 P9 ]" {
+		t.Errorf("pages %s, want every greeting", got)
+	}
+}
+
+// A file held by another open Bolt cannot be opened twice.
+func TestBoltLocked(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "greetings.db")
+	b, err := OpenBolt(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+	if other, err := OpenBolt(path, 0); err == nil {
+		other.Close()
+		t.Fatal("opened a file that is in use")
+	}
+}
+
+// Greet counts are what survive a restart.
+func TestBoltCountsSurviveReopen(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "greetings.db")
+	b, err := OpenBolt(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		b.Increment(ctx, "nanda r")
+	}
+	b.Increment(ctx, "kumar")
+	if err := b.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err = OpenBolt(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, err := b.Increment(ctx, "nanda r"); err != nil || n != 4 {
+		t.Fatalf("Increment after reopening = %d, %v; want 4", n, err)
+	}
+	if n, err := b.Reset(ctx, "kumar"); err != nil || n != 1 {
+		t.Fatalf("Reset after reopening = %d, %v; want the previous count 1", n, err)
+	}
+	if err := b.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A reset is persisted too.
+	b, err = OpenBolt(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+	if n, err := b.Increment(ctx, "kumar"); err != nil || n != 1 {
+		t.Errorf("Increment after a reset and reopening = %d, %v; want 1", n, err)
+	}
+	if n, err := b.Reset(ctx, "nobody"); err != nil || n != 0 {
+		t.Errorf("Reset of an unknown key = %d, %v; want 0", n, err)
+	}
+}
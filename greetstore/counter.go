@@ -0,0 +1,51 @@
+package greetstore
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"../greetpb"
+)
+
+// Counter keeps how many times each person has been greeted.
+// Implementations are safe for concurrent use.
+type Counter interface {
+	// Increment adds one to key's count and returns the new count.
+	Increment(ctx context.Context, key string) (uint64, error)
+	// Reset sets key's count back to zero and returns the previous count.
+	Reset(ctx context.Context, key string) (uint64, error)
+}
+
+// CountKey normalizes a greeting's name for use as a Counter key: case is
+// ignored and runs of spaces count as one. It is empty for a greeting
+// without a name.
+func CountKey(g *greetpb.Greeting) string {
+	return strings.ToLower(strings.Join(strings.Fields(g.GetFirstName()+" "+g.GetLastName()), " "))
+}
+
+// MemoryCounter keeps counts in process; they are lost on restart.
+type MemoryCounter struct {
+	mu     sync.Mutex
+	counts map[string]uint64
+}
+
+// NewMemoryCounter returns a counter with every count at zero.
+func NewMemoryCounter() *MemoryCounter {
+	return &MemoryCounter{counts: make(map[string]uint64)}
+}
+
+func (c *MemoryCounter) Increment(ctx context.Context, key string) (uint64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.counts[key]++
+	return c.counts[key], nil
+}
+
+func (c *MemoryCounter) Reset(ctx context.Context, key string) (uint64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := c.counts[key]
+	delete(c.counts, key)
+	return n, nil
+}
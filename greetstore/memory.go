@@ -0,0 +1,75 @@
+package greetstore
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"sync"
+
+	"../greetpb"
+	"github.com/golang/protobuf/ptypes"
+)
+
+// Memory keeps the most recent greetings in process. Once it holds max
+// records, each new one replaces the oldest.
+type Memory struct {
+	max int
+
+	mu      sync.RWMutex
+	seq     uint64
+	entries []memoryEntry
+}
+
+type memoryEntry struct {
+	key []byte
+	rec *greetpb.GreetingRecord
+}
+
+// NewMemory returns an empty store holding at most max records.
+func NewMemory(max int) *Memory {
+	return &Memory{max: max}
+}
+
+func (m *Memory) Add(ctx context.Context, rec *greetpb.GreetingRecord) error {
+	t, err := ptypes.Timestamp(rec.GetTime())
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.seq++
+	e := memoryEntry{key: makeKey(t, m.seq), rec: rec}
+	// Records nearly always arrive in time order, so this is an append.
+	i := sort.Search(len(m.entries), func(i int) bool { return bytes.Compare(m.entries[i].key, e.key) > 0 })
+	m.entries = append(m.entries, memoryEntry{})
+	copy(m.entries[i+1:], m.entries[i:])
+	m.entries[i] = e
+	if len(m.entries) > m.max {
+		m.entries[0] = memoryEntry{}
+		m.entries = m.entries[1:]
+	}
+	return nil
+}
+
+func (m *Memory) List(ctx context.Context, q Query) ([]*greetpb.GreetingRecord, string, error) {
+	from, exclusive, err := q.startKey()
+	if err != nil {
+		return nil, "", err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	i := sort.Search(len(m.entries), func(i int) bool {
+		c := bytes.Compare(m.entries[i].key, from)
+		return c > 0 || c == 0 && !exclusive
+	})
+	var recs []*greetpb.GreetingRecord
+	for ; i < len(m.entries) && !q.beyond(m.entries[i].key); i++ {
+		if len(recs) == q.PageSize {
+			return recs, encodeToken(m.entries[i-1].key), nil
+		}
+		recs = append(recs, m.entries[i].rec)
+	}
+	return recs, "", nil
+}
+
+func (m *Memory) Close() error { return nil }
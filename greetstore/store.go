@@ -0,0 +1,83 @@
+// Package greetstore keeps the history of greetings handled by greet_server
+// and how often each person was greeted. Memory and MemoryCounter hold them
+// in process; Bolt persists both to a bbolt file.
+package greetstore
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"time"
+
+	"../greetpb"
+)
+
+// Store records greetings and lists them in time order. Implementations are
+// safe for concurrent use.
+type Store interface {
+	Add(ctx context.Context, rec *greetpb.GreetingRecord) error
+	// List returns up to q.PageSize records and the token for the next
+	// page, which is empty when there are no more.
+	List(ctx context.Context, q Query) ([]*greetpb.GreetingRecord, string, error)
+	Close() error
+}
+
+// Query selects records from Start (inclusive) to End (exclusive); zero
+// times leave that side open.
+type Query struct {
+	Start     time.Time
+	End       time.Time
+	PageSize  int
+	PageToken string
+}
+
+// ErrInvalidPageToken is returned by List for a token it did not issue.
+var ErrInvalidPageToken = errors.New("greetstore: invalid page token")
+
+// Records are keyed by their time in nanoseconds followed by a sequence
+// number, both big endian, so that keys sort in time order and records from
+// the same instant stay distinct.
+const keyLen = 16
+
+func makeKey(t time.Time, seq uint64) []byte {
+	k := make([]byte, keyLen)
+	binary.BigEndian.PutUint64(k, uint64(t.UnixNano()))
+	binary.BigEndian.PutUint64(k[8:], seq)
+	return k
+}
+
+// timeKey is the smallest key at or after t.
+func timeKey(t time.Time) []byte {
+	return makeKey(t, 0)
+}
+
+func encodeToken(key []byte) string {
+	return base64.RawURLEncoding.EncodeToString(key)
+}
+
+func decodeToken(token string) ([]byte, error) {
+	key, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(key) != keyLen {
+		return nil, ErrInvalidPageToken
+	}
+	return key, nil
+}
+
+// startKey is where a listing begins: just after the page token's key, or
+// at the start time.
+func (q Query) startKey() (key []byte, exclusive bool, err error) {
+	if q.PageToken != "" {
+		key, err := decodeToken(q.PageToken)
+		return key, true, err
+	}
+	if q.Start.IsZero() {
+		return nil, false, nil
+	}
+	return timeKey(q.Start), false, nil
+}
+
+// beyond reports whether key lies past the end of the query.
+func (q Query) beyond(key []byte) bool {
+	return !q.End.IsZero() && binary.BigEndian.Uint64(key) >= uint64(q.End.UnixNano())
+}
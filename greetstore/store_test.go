@@ -0,0 +1,147 @@
+package greetstore
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"../greetpb"
+	"github.com/golang/protobuf/ptypes"
+)
+
+var base = time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+// stores opens each Store implementation holding at most max records.
+var stores = map[string]func(t *testing.T, max int) Store{
+	"memory": func(t *testing.T, max int) Store { return NewMemory(max) },
+	"bolt": func(t *testing.T, max int) Store {
+		b, err := OpenBolt(filepath.Join(t.TempDir(), "greetings.db"), max)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { b.Close() })
+		return b
+	},
+}
+
+// record is a greeting of name made sec seconds after base.
+func record(t *testing.T, name string, sec int) *greetpb.GreetingRecord {
+	t.Helper()
+	ts, err := ptypes.TimestampProto(base.Add(time.Duration(sec) * time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &greetpb.GreetingRecord{Greeting: &greetpb.Greeting{FirstName: name}, Time: ts}
+}
+
+// addRecords adds P0 to P9, one second apart, P5 last as if it arrived late.
+func addRecords(t *testing.T, s Store) {
+	t.Helper()
+	for _, i := range []int{0, 1, 2, 3, 4, 6, 7, 8, 9, 5} {
+		if err := s.Add(context.Background(), record(t, fmt.Sprint("P", i), i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func names(recs []*greetpb.GreetingRecord) string {
+	s := ""
+	for _, rec := range recs {
+		s += rec.GetGreeting().GetFirstName() + " "
+	}
+	return s
+}
+
+// listAll follows the page tokens from q to the end.
+func listAll(t *testing.T, s Store, q Query) (pages []string) {
+	t.Helper()
+	for {
+		recs, next, err := s.List(context.Background(), q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pages = append(pages, names(recs))
+		if next == "" {
+			return pages
+		}
+		q.PageToken = next
+	}
+}
+
+func TestStoreList(t *testing.T) {
+	for name, open := range stores {
+		t.Run(name, func(t *testing.T) {
+			s := open(t, 100)
+			addRecords(t, s)
+			for _, tc := range []struct {
+				name  string
+				q     Query
+				pages string
+			}{
+				{"one page", Query{PageSize: 10}, "[P0 P1 P2 P3 P4 P5 P6 P7 P8 P9 ]"},
+				{"several pages", Query{PageSize: 3}, "[P0 P1 P2  P3 P4 P5  P6 P7 P8  P9 ]"},
+				{"exact pages", Query{PageSize: 5}, "[P0 P1 P2 P3 P4  P5 P6 P7 P8 P9 ]"},
+				{"start", Query{PageSize: 10, Start: base.Add(7 * time.Second)}, "[P7 P8 P9 ]"},
+				{"end", Query{PageSize: 10, End: base.Add(2 * time.Second)}, "[P0 P1 ]"},
+				{"start and end", Query{PageSize: 10, Start: base.Add(2 * time.Second), End: base.Add(5 * time.Second)}, "[P2 P3 P4 ]"},
+				{"pages between start and end", Query{PageSize: 2, Start: base.Add(2 * time.Second), End: base.Add(7 * time.Second)}, "[P2 P3  P4 P5  P6 ]"},
+				{"start between records", Query{PageSize: 10, Start: base.Add(8500 * time.Millisecond)}, "[P9 ]"},
+				{"nothing in range", Query{PageSize: 10, Start: base.Add(time.Hour)}, "[]"},
+			} {
+				if got := fmt.Sprint(listAll(t, s, tc.q)); got != tc.pages {
+					t.Errorf("%s: pages %s, want %s", tc.name, got, tc.pages)
+				}
+			}
+		})
+	}
+}
+
+func TestStoreSameInstant(t *testing.T) {
+	for name, open := range stores {
+		t.Run(name, func(t *testing.T) {
+			s := open(t, 100)
+			for _, n := range []string{"a", "b", "c"} {
+				if err := s.Add(context.Background(), record(t, n, 0)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := fmt.Sprint(listAll(t, s, Query{PageSize: 2})); got != "[a b  c ]" {
+				t.Errorf("pages %s, want every greeting once in the order added", got)
+			}
+		})
+	}
+}
+
+func TestStoreInvalidPageToken(t *testing.T) {
+	for name, open := range stores {
+		t.Run(name, func(t *testing.T) {
+			s := open(t, 100)
+			addRecords(t, s)
+			for _, token := range []string{
+				"not a token!",
+				base64.RawURLEncoding.EncodeToString([]byte("short")),
+				base64.StdEncoding.EncodeToString(make([]byte, keyLen)),
+			} {
+				if _, _, err := s.List(context.Background(), Query{PageSize: 10, PageToken: token}); err != ErrInvalidPageToken {
+					t.Errorf("token %q: %v, want ErrInvalidPageToken", token, err)
+				}
+			}
+		})
+	}
+}
+
+func TestStoreKeepsLatest(t *testing.T) {
+	for name, open := range stores {
+		t.Run(name, func(t *testing.T) {
+			s := open(t, 3)
+			addRecords(t, s)
+			// The oldest greeting goes, even the late P5.
+			if got := fmt.Sprint(listAll(t, s, Query{PageSize: 10})); got != "[P7 P8 P9 ]" {
+				t.Errorf("pages %s, want the 3 latest greetings", got)
+			}
+		})
+	}
+}
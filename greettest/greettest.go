@@ -0,0 +1,61 @@
+// Package greettest runs GreetService in-process on a bufconn listener so
+// tests can exercise it without opening a real port.
+package greettest
+
+import (
+	"context"
+	"net"
+
+	"../greetpb"
+	"../greetserver"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const bufSize = 1024 * 1024
+
+// Server is a running in-process gRPC server.
+type Server struct {
+	Listener *bufconn.Listener
+	GRPC     *grpc.Server
+}
+
+// NewServer starts impl on a bufconn listener. A nil impl serves
+// greetserver.Server with no delay between streamed messages.
+func NewServer(impl greetpb.GreetServiceServer, opts ...grpc.ServerOption) *Server {
+	if impl == nil {
+		impl = &greetserver.Server{}
+	}
+	lis := bufconn.Listen(bufSize)
+	s := grpc.NewServer(opts...)
+	greetpb.RegisterGreetServiceServer(s, impl)
+	go func() {
+		_ = s.Serve(lis)
+	}()
+	return &Server{Listener: lis, GRPC: s}
+}
+
+// Dial opens a client connection to the server.
+func (s *Server) Dial(ctx context.Context, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	dialer := func(ctx context.Context, _ string) (net.Conn, error) {
+		return s.Listener.DialContext(ctx)
+	}
+	opts = append([]grpc.DialOption{grpc.WithContextDialer(dialer), grpc.WithInsecure()}, opts...)
+	return grpc.DialContext(ctx, "bufnet", opts...)
+}
+
+// Client dials the server and returns a GreetService client along with the
+// connection that must be closed by the caller.
+func (s *Server) Client(ctx context.Context, opts ...grpc.DialOption) (greetpb.GreetServiceClient, *grpc.ClientConn, error) {
+	conn, err := s.Dial(ctx, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return greetpb.NewGreetServiceClient(conn), conn, nil
+}
+
+// Close stops the server and the listener.
+func (s *Server) Close() {
+	s.GRPC.Stop()
+	_ = s.Listener.Close()
+}
@@ -0,0 +1,249 @@
+package greettest
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"../greetpb"
+	"../greetpb/greetmock"
+	"../greetserver"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func newClient(t *testing.T, impl greetpb.GreetServiceServer) greetpb.GreetServiceClient {
+	t.Helper()
+	srv := NewServer(impl)
+	t.Cleanup(srv.Close)
+	client, conn, err := srv.Client(context.Background())
+	if err != nil {
+		t.Fatalf("Client: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return client
+}
+
+func greeting(first, last string) *greetpb.GreetRequest {
+	return &greetpb.GreetRequest{Greeting: &greetpb.Greeting{FirstName: first, LastName: last}}
+}
+
+func TestGreet(t *testing.T) {
+	client := newClient(t, nil)
+	res, err := client.Greet(context.Background(), greeting("Nanda", "R"))
+	if err != nil {
+		t.Fatalf("Greet: %v", err)
+	}
+	if got, want := res.GetResult(), "Hi Nanda R"; got != want {
+		t.Errorf("Result = %q, want %q", got, want)
+	}
+}
+
+func TestGreetError(t *testing.T) {
+	client := newClient(t, &greetmock.Server{
+		GreetFunc: func(ctx context.Context, req *greetpb.GreetRequest) (*greetpb.GreetResponse, error) {
+			return nil, status.Error(codes.NotFound, "nobody to greet")
+		},
+	})
+	_, err := client.Greet(context.Background(), greeting("Nanda", "R"))
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("Greet error = %v, want NotFound", err)
+	}
+}
+
+func TestGreetManyTimes(t *testing.T) {
+	client := newClient(t, nil)
+	stream, err := client.GreetManyTimes(context.Background(), greeting("Nanda", "R"))
+	if err != nil {
+		t.Fatalf("GreetManyTimes: %v", err)
+	}
+	n := 0
+	for {
+		res, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Recv %d: %v", n, err)
+		}
+		if res.GetResumeToken() == "" {
+			t.Errorf("response %d has no resume_token", n)
+		}
+		n++
+	}
+	if n != 10 {
+		t.Errorf("received %d responses, want 10", n)
+	}
+}
+
+func TestGreetManyTimesCancel(t *testing.T) {
+	client := newClient(t, &greetserver.Server{Interval: time.Hour})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := client.GreetManyTimes(ctx, greeting("Nanda", "R"))
+	if err != nil {
+		t.Fatalf("GreetManyTimes: %v", err)
+	}
+	if _, err := stream.Recv(); err != nil {
+		t.Fatalf("first Recv: %v", err)
+	}
+	cancel()
+	if _, err := stream.Recv(); status.Code(err) != codes.Canceled {
+		t.Fatalf("Recv after cancel = %v, want Canceled", err)
+	}
+}
+
+func TestGreetManyTimesDeadline(t *testing.T) {
+	client := newClient(t, &greetserver.Server{Interval: time.Hour})
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	stream, err := client.GreetManyTimes(ctx, greeting("Nanda", "R"))
+	if err != nil {
+		t.Fatalf("GreetManyTimes: %v", err)
+	}
+	for {
+		if _, err = stream.Recv(); err != nil {
+			break
+		}
+	}
+	if status.Code(err) != codes.DeadlineExceeded {
+		t.Fatalf("Recv = %v, want DeadlineExceeded", err)
+	}
+}
+
+func TestGreetManyTimesBadResumeToken(t *testing.T) {
+	client := newClient(t, nil)
+	req := greeting("Nanda", "R")
+	req.ResumeToken = "not a token"
+	stream, err := client.GreetManyTimes(context.Background(), req)
+	if err != nil {
+		t.Fatalf("GreetManyTimes: %v", err)
+	}
+	if _, err := stream.Recv(); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("Recv = %v, want InvalidArgument", err)
+	}
+}
+
+func TestLongGreet(t *testing.T) {
+	client := newClient(t, nil)
+	stream, err := client.LongGreet(context.Background())
+	if err != nil {
+		t.Fatalf("LongGreet: %v", err)
+	}
+	for _, name := range []string{"nanda", "kumar", "stephane"} {
+		if err := stream.Send(greeting(name, "")); err != nil {
+			t.Fatalf("Send: %v", err)
+		}
+	}
+	res, err := stream.CloseAndRecv()
+	if err != nil {
+		t.Fatalf("CloseAndRecv: %v", err)
+	}
+	if got, want := res.GetResult(), "Hello nanda! Hello kumar! Hello stephane! "; got != want {
+		t.Errorf("Result = %q, want %q", got, want)
+	}
+}
+
+func TestLongGreetEmpty(t *testing.T) {
+	client := newClient(t, nil)
+	stream, err := client.LongGreet(context.Background())
+	if err != nil {
+		t.Fatalf("LongGreet: %v", err)
+	}
+	res, err := stream.CloseAndRecv()
+	if err != nil {
+		t.Fatalf("CloseAndRecv: %v", err)
+	}
+	if res.GetResult() != "" {
+		t.Errorf("Result = %q, want empty", res.GetResult())
+	}
+}
+
+func TestLongGreetCancel(t *testing.T) {
+	client := newClient(t, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := client.LongGreet(ctx)
+	if err != nil {
+		t.Fatalf("LongGreet: %v", err)
+	}
+	if err := stream.Send(greeting("nanda", "")); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	cancel()
+	if _, err := stream.CloseAndRecv(); status.Code(err) != codes.Canceled {
+		t.Fatalf("CloseAndRecv = %v, want Canceled", err)
+	}
+}
+
+func TestGreetEveryOne(t *testing.T) {
+	client := newClient(t, nil)
+	stream, err := client.GreetEveryOne(context.Background())
+	if err != nil {
+		t.Fatalf("GreetEveryOne: %v", err)
+	}
+	names := []string{"nanda", "kumar", "stephane"}
+	for i, name := range names {
+		req := greeting(name, "")
+		req.SequenceId = uint64(i + 1)
+		if err := stream.Send(req); err != nil {
+			t.Fatalf("Send: %v", err)
+		}
+		res, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("Recv: %v", err)
+		}
+		if got, want := res.GetResult(), "Hello "+name+"! "; got != want {
+			t.Errorf("Result = %q, want %q", got, want)
+		}
+		if res.GetAckSequenceId() != req.SequenceId {
+			t.Errorf("AckSequenceId = %d, want %d", res.GetAckSequenceId(), req.SequenceId)
+		}
+	}
+	if err := stream.CloseSend(); err != nil {
+		t.Fatalf("CloseSend: %v", err)
+	}
+	if _, err := stream.Recv(); err != io.EOF {
+		t.Fatalf("Recv after CloseSend = %v, want io.EOF", err)
+	}
+}
+
+func TestGreetEveryOneCancel(t *testing.T) {
+	client := newClient(t, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := client.GreetEveryOne(ctx)
+	if err != nil {
+		t.Fatalf("GreetEveryOne: %v", err)
+	}
+	if err := stream.Send(greeting("nanda", "")); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if _, err := stream.Recv(); err != nil {
+		t.Fatalf("Recv: %v", err)
+	}
+	cancel()
+	if _, err := stream.Recv(); status.Code(err) != codes.Canceled {
+		t.Fatalf("Recv after cancel = %v, want Canceled", err)
+	}
+}
+
+func TestGreetEveryOneServerError(t *testing.T) {
+	client := newClient(t, &greetmock.Server{
+		GreetEveryOneFunc: func(stream greetpb.GreetService_GreetEveryOneServer) error {
+			if _, err := stream.Recv(); err != nil {
+				return err
+			}
+			return status.Error(codes.Internal, "boom")
+		},
+	})
+	stream, err := client.GreetEveryOne(context.Background())
+	if err != nil {
+		t.Fatalf("GreetEveryOne: %v", err)
+	}
+	if err := stream.Send(greeting("nanda", "")); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if _, err := stream.Recv(); status.Code(err) != codes.Internal {
+		t.Fatalf("Recv = %v, want Internal", err)
+	}
+}